@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Agent is a Dialogflow ES agent as found in an export ZIP
+type Agent struct {
+	Meta     Meta
+	Intents  []*Intent
+	Entities []*Entity
+}
+
+// Meta is the content of the agent.json file of the export
+type Meta struct {
+	Description        string   `json:"description"`
+	Language           string   `json:"language"`
+	SupportedLanguages []string `json:"supportedLanguages"`
+	Webhook            Webhook  `json:"webhook"`
+	DefaultTimezone    string   `json:"defaultTimezone,omitempty"`
+}
+
+// Webhook is the fulfillment configuration of the agent
+type Webhook struct {
+	URL       string `json:"url"`
+	Available bool   `json:"available"`
+}
+
+// Intent is an intent definition, stored in intents/<name>.json along with
+// its training phrases in intents/<name>_usersays_<lang>.json
+type Intent struct {
+	ID                    string     `json:"id"`
+	Name                  string     `json:"name"`
+	Auto                  bool       `json:"auto"`
+	Contexts              []string   `json:"contexts"`
+	Responses             []Response `json:"responses"`
+	Priority              int        `json:"priority"`
+	WebhookUsed           bool       `json:"webhookUsed"`
+	WebhookForSlotFilling bool       `json:"webhookForSlotFilling"`
+	FallbackIntent        bool       `json:"fallbackIntent"`
+	Events                []Event    `json:"events"`
+	ParentID              string     `json:"parentId,omitempty"`
+	RootParentID          string     `json:"rootParentId,omitempty"`
+
+	// UserSays holds the training phrases per language
+	UserSays map[string][]UserSays `json:"-"`
+}
+
+// Response is what an intent does once matched
+type Response struct {
+	ResetContexts    bool              `json:"resetContexts"`
+	Action           string            `json:"action"`
+	AffectedContexts []AffectedContext `json:"affectedContexts"`
+	Parameters       []Parameter       `json:"parameters"`
+	Messages         []Message         `json:"messages"`
+}
+
+// AffectedContext is an output context set when the intent is matched
+type AffectedContext struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Lifespan   int                    `json:"lifespan"`
+}
+
+// Parameter is an intent parameter. DataType references an entity such as
+// "@alcohol" or "@sys.number" and Value is usually "$name" or a reference to a
+// context parameter such as "#search-followup.alcohol".
+type Parameter struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Required     bool   `json:"required"`
+	DataType     string `json:"dataType"`
+	Value        string `json:"value"`
+	DefaultValue string `json:"defaultValue"`
+	IsList       bool   `json:"isList"`
+}
+
+// Message is a static response of the intent, Speech being either a string or
+// a list of strings in the export
+type Message struct {
+	Type   interface{}     `json:"type"`
+	Lang   string          `json:"lang"`
+	Speech json.RawMessage `json:"speech,omitempty"`
+}
+
+// Texts returns the speech variants of the message
+func (m Message) Texts() []string {
+	var ss []string
+	if err := json.Unmarshal(m.Speech, &ss); err == nil {
+		return ss
+	}
+	var s string
+	if err := json.Unmarshal(m.Speech, &s); err == nil && s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+// Event is an event triggering the intent
+type Event struct {
+	Name string `json:"name"`
+}
+
+// UserSays is a training phrase, made of parts which are either plain text
+// or annotated entity values
+type UserSays struct {
+	ID         string `json:"id"`
+	Data       []Part `json:"data"`
+	IsTemplate bool   `json:"isTemplate"`
+	Count      int    `json:"count"`
+}
+
+// Text returns the complete text of the training phrase
+func (u UserSays) Text() string {
+	var b strings.Builder
+	for _, p := range u.Data {
+		b.WriteString(p.Text)
+	}
+	return b.String()
+}
+
+// Part is a part of a training phrase. Meta holds the entity type, for
+// example "@alcohol", and Alias the parameter name.
+type Part struct {
+	Text        string `json:"text"`
+	Alias       string `json:"alias,omitempty"`
+	Meta        string `json:"meta,omitempty"`
+	UserDefined bool   `json:"userDefined"`
+}
+
+// Entity is an entity type, stored in entities/<name>.json along with its
+// entries in entities/<name>_entries_<lang>.json
+type Entity struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	IsOverridable        bool   `json:"isOverridable"`
+	IsEnum               bool   `json:"isEnum"`
+	IsRegexp             bool   `json:"isRegexp"`
+	AutomatedExpansion   bool   `json:"automatedExpansion"`
+	AllowFuzzyExtraction bool   `json:"allowFuzzyExtraction"`
+
+	// Entries holds the entries per language
+	Entries map[string][]Entry `json:"-"`
+}
+
+// Entry is an entity value along with its synonyms
+type Entry struct {
+	Value    string   `json:"value"`
+	Synonyms []string `json:"synonyms"`
+}
+
+// Action returns the action of the intent, if any
+func (i *Intent) Action() string {
+	if len(i.Responses) == 0 {
+		return ""
+	}
+	return i.Responses[0].Action
+}
+
+// Intent returns the intent with the given name or nil
+func (a *Agent) Intent(name string) *Intent {
+	for _, i := range a.Intents {
+		if i.Name == name {
+			return i
+		}
+	}
+	return nil
+}
+
+// Entity returns the entity with the given name or nil
+func (a *Agent) Entity(name string) *Entity {
+	for _, e := range a.Entities {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// sortAgent sorts the intents and entities by name so that the agent is
+// always processed in the same order
+func sortAgent(a *Agent) {
+	sort.Slice(a.Intents, func(i, j int) bool { return a.Intents[i].Name < a.Intents[j].Name })
+	sort.Slice(a.Entities, func(i, j int) bool { return a.Entities[i].Name < a.Entities[j].Name })
+}
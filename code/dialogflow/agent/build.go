@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// T returns a plain text part of a training phrase
+func T(text string) Part {
+	return Part{Text: text}
+}
+
+// E returns a part of a training phrase annotated with an entity, for example
+// E("rum", "@alcohol", "alcohol")
+func E(text, entity, alias string) Part {
+	return Part{Text: text, Meta: entity, Alias: alias, UserDefined: true}
+}
+
+// Phrase returns a training phrase made of the given parts
+func Phrase(parts ...Part) UserSays {
+	return UserSays{Data: parts}
+}
+
+// Phrases returns training phrases without any annotation
+func Phrases(texts ...string) []UserSays {
+	us := make([]UserSays, 0, len(texts))
+	for _, t := range texts {
+		us = append(us, Phrase(T(t)))
+	}
+	return us
+}
+
+// NewParameter returns a parameter whose value is taken from the training
+// phrase annotations with the same alias
+func NewParameter(name, dataType string, required bool) Parameter {
+	return Parameter{Name: name, DataType: dataType, Value: "$" + name, Required: required}
+}
+
+// TextMessage returns a static text response, one of the texts being picked
+// at random by Dialogflow
+func TextMessage(lang string, texts ...string) Message {
+	b, _ := json.Marshal(texts)
+	return Message{Type: 0, Lang: lang, Speech: b}
+}
+
+// NewEntry returns an entity entry, the value being one of its synonyms
+func NewEntry(value string, synonyms ...string) Entry {
+	return Entry{Value: value, Synonyms: append([]string{value}, synonyms...)}
+}
+
+// id returns a stable UUID derived from the kind and name of an object so
+// that the generated exports don't change between builds
+func id(kind, name string) string {
+	h := sha1.Sum([]byte(kind + ":" + name))
+	h[6] = (h[6] & 0x0f) | 0x50
+	h[8] = (h[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// Has returns true if the text is one of the synonyms of the entity in the
+// given language, ignoring case
+func (e *Entity) Has(lang, text string) bool {
+	for _, en := range e.Entries[lang] {
+		for _, s := range en.Synonyms {
+			if strings.EqualFold(s, text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Languages returns the default language followed by the supported ones
+func (a *Agent) Languages() []string {
+	ls := []string{a.Meta.Language}
+	for _, l := range a.Meta.SupportedLanguages {
+		if l != a.Meta.Language {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
+// Actions returns the sorted actions of the intents using the webhook
+func (a *Agent) Actions() []string {
+	set := make(map[string]bool)
+	for _, i := range a.Intents {
+		if i.WebhookUsed && i.Action() != "" {
+			set[i.Action()] = true
+		}
+	}
+	as := make([]string, 0, len(set))
+	for n := range set {
+		as = append(as, n)
+	}
+	sort.Strings(as)
+	return as
+}
+
+// Validate checks that the names are unique, that the parameters reference
+// known entities and that the annotated parts of the training phrases match a
+// parameter of their intent. All the problems are reported at once.
+func (a *Agent) Validate() error {
+	var errs []string
+	entities := make(map[string]bool)
+	for _, e := range a.Entities {
+		if entities[e.Name] {
+			errs = append(errs, fmt.Sprintf("entity %q: defined twice", e.Name))
+		}
+		entities[e.Name] = true
+		if len(e.Entries[a.Meta.Language]) == 0 {
+			errs = append(errs, fmt.Sprintf("entity %q: no entry for %s", e.Name, a.Meta.Language))
+		}
+	}
+
+	intents := make(map[string]bool)
+	for _, in := range a.Intents {
+		if intents[in.Name] {
+			errs = append(errs, fmt.Sprintf("intent %q: defined twice", in.Name))
+		}
+		intents[in.Name] = true
+
+		params := make(map[string]bool)
+		for _, r := range in.Responses {
+			for _, p := range r.Parameters {
+				params[p.Name] = true
+				n := strings.TrimPrefix(p.DataType, "@")
+				if !strings.HasPrefix(n, "sys.") && !entities[n] {
+					errs = append(errs, fmt.Sprintf("intent %q: parameter %q uses unknown entity %q", in.Name, p.Name, p.DataType))
+				}
+			}
+		}
+		for lang, uss := range in.UserSays {
+			for _, us := range uss {
+				for _, p := range us.Data {
+					if p.Alias != "" && !params[p.Alias] {
+						errs = append(errs, fmt.Sprintf("intent %q: %s phrase %q annotates unknown parameter %q", in.Name, lang, us.Text(), p.Alias))
+					}
+					if e := a.Entity(strings.TrimPrefix(p.Meta, "@")); e != nil && !e.Has(lang, p.Text) {
+						errs = append(errs, fmt.Sprintf("intent %q: %s phrase %q annotates %q which isn't a %s synonym", in.Name, lang, us.Text(), p.Text, p.Meta))
+					}
+				}
+			}
+		}
+		if !in.FallbackIntent && len(in.Events) == 0 && len(in.UserSays[a.Meta.Language]) == 0 {
+			errs = append(errs, fmt.Sprintf("intent %q: no training phrase for %s", in.Name, a.Meta.Language))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid agent:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// CheckActions compares the actions of the agent with the actions handled by
+// the webhook, returning an error listing the actions without handler and the
+// handlers no intent triggers
+func CheckActions(a *Agent, handled []string) error {
+	declared := make(map[string]bool)
+	var errs []string
+	for _, n := range a.Actions() {
+		declared[n] = true
+	}
+	registered := make(map[string]bool)
+	for _, n := range handled {
+		registered[n] = true
+	}
+	for _, n := range a.Actions() {
+		if !registered[n] {
+			errs = append(errs, fmt.Sprintf("action %q has no webhook handler", n))
+		}
+	}
+	sorted := append([]string{}, handled...)
+	sort.Strings(sorted)
+	for _, n := range sorted {
+		if !declared[n] {
+			errs = append(errs, fmt.Sprintf("handler %q isn't used by any intent", n))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("agent and webhook don't match:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
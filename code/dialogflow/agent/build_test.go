@@ -0,0 +1,79 @@
+package agent_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+	"github.com/Depado/articles/code/dialogflow/definition"
+	"github.com/Depado/articles/code/dialogflow/webhook"
+)
+
+func intent(name, action string, webhook bool) *agent.Intent {
+	return &agent.Intent{
+		Name:        name,
+		WebhookUsed: webhook,
+		Responses:   []agent.Response{{Action: action}},
+	}
+}
+
+func TestCheckActions(t *testing.T) {
+	a := &agent.Agent{Intents: []*agent.Intent{
+		intent("Random", "random", true),
+		intent("Search", "search", true),
+		intent("Search again", "search", true),
+		intent("Welcome", "input.welcome", false),
+		intent("Small talk", "", true),
+	}}
+	tests := []struct {
+		name    string
+		handled []string
+		errs    []string
+	}{
+		{"match", []string{"search", "random"}, nil},
+		{"missing handler", []string{"random"}, []string{`action "search" has no webhook handler`}},
+		{"unused handler", []string{"random", "search", "recipe.start"}, []string{`handler "recipe.start" isn't used by any intent`}},
+		{"both", []string{"random", "history.list", "favorites.add"}, []string{
+			`action "search" has no webhook handler`,
+			`handler "favorites.add" isn't used by any intent`,
+			`handler "history.list" isn't used by any intent`,
+		}},
+		{"no handler", nil, []string{
+			`action "random" has no webhook handler`,
+			`action "search" has no webhook handler`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := agent.CheckActions(a, tt.handled)
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			lines := strings.Split(err.Error(), "\n  ")[1:]
+			if strings.Join(lines, "\n") != strings.Join(tt.errs, "\n") {
+				t.Errorf("got errors\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(tt.errs, "\n"))
+			}
+		})
+	}
+}
+
+// TestDefinition makes sure the shipped agent is valid and that every action it
+// declares is handled by the webhook, and the other way around
+func TestDefinition(t *testing.T) {
+	a, err := definition.Agent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Validate(); err != nil {
+		t.Error(err)
+	}
+	if err = agent.CheckActions(a, webhook.New(nil, webhook.Options{}).Actions()); err != nil {
+		t.Error(err)
+	}
+}
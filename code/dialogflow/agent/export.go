@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// exportVersion is the version of the export format written in package.json
+const exportVersion = "1.0.0"
+
+// createFunc creates a file of the export, the name using the export layout
+type createFunc func(name string) (io.Writer, error)
+
+// WriteZip writes the agent as an export ZIP that can be imported or restored
+// in the Dialogflow console. Missing IDs are derived from the names so that
+// the output only changes when the agent does.
+func (a *Agent) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	create := func(name string) (io.Writer, error) {
+		// The modification time is left empty to keep the archive reproducible
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	}
+	if err := a.write(create); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// SaveZip writes the agent export to the given path
+func (a *Agent) SaveZip(p string) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if err = a.WriteZip(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SaveEntities writes the entities in dir, one file for the entity and one per
+// language for its entries, as they are named in the entities directory of an
+// export. The other JSON files of dir, left by entities or languages that
+// don't exist anymore, are removed.
+func (a *Agent) SaveEntities(dir string) error {
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	written := make(map[string]bool)
+	create := func(name string) (io.Writer, error) {
+		f, err := os.Create(filepath.Join(dir, filepath.Base(name)))
+		if err == nil {
+			files = append(files, f)
+			written[filepath.Base(name)] = true
+		}
+		return f, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	sortAgent(a)
+	if err := a.writeEntities(create); err != nil {
+		return err
+	}
+	stale, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, p := range stale {
+		if written[filepath.Base(p)] {
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Agent) write(create createFunc) error {
+	sortAgent(a)
+	if err := writeJSON(create, "package.json", map[string]string{"version": exportVersion}); err != nil {
+		return err
+	}
+	if err := writeJSON(create, "agent.json", a.Meta); err != nil {
+		return err
+	}
+
+	for _, in := range a.Intents {
+		normalize(in)
+		if err := writeJSON(create, "intents/"+in.Name+".json", in); err != nil {
+			return err
+		}
+		for _, lang := range a.Languages() {
+			us := in.UserSays[lang]
+			if len(us) == 0 {
+				continue
+			}
+			for i := range us {
+				if us[i].ID == "" {
+					us[i].ID = id("usersays", in.Name+"/"+lang+"/"+us[i].Text())
+				}
+			}
+			if err := writeJSON(create, "intents/"+in.Name+userSaysInfix+lang+".json", us); err != nil {
+				return err
+			}
+		}
+	}
+	return a.writeEntities(create)
+}
+
+func (a *Agent) writeEntities(create createFunc) error {
+	for _, e := range a.Entities {
+		if e.ID == "" {
+			e.ID = id("entity", e.Name)
+		}
+		if err := writeJSON(create, "entities/"+e.Name+".json", e); err != nil {
+			return err
+		}
+		for _, lang := range a.Languages() {
+			if es := e.Entries[lang]; len(es) > 0 {
+				if err := writeJSON(create, "entities/"+e.Name+entriesInfix+lang+".json", es); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// normalize sets the missing IDs of the intent and its parameters and replaces
+// the nil slices, the console expecting empty lists rather than null values
+func normalize(in *Intent) {
+	if in.ID == "" {
+		in.ID = id("intent", in.Name)
+	}
+	if in.Contexts == nil {
+		in.Contexts = []string{}
+	}
+	if in.Events == nil {
+		in.Events = []Event{}
+	}
+	for i := range in.Responses {
+		r := &in.Responses[i]
+		if r.AffectedContexts == nil {
+			r.AffectedContexts = []AffectedContext{}
+		}
+		if r.Parameters == nil {
+			r.Parameters = []Parameter{}
+		}
+		if r.Messages == nil {
+			r.Messages = []Message{}
+		}
+		for j := range r.Parameters {
+			if r.Parameters[j].ID == "" {
+				r.Parameters[j].ID = id("parameter", in.Name+"/"+r.Parameters[j].Name)
+			}
+		}
+	}
+}
+
+// writeJSON adds an indented JSON file to the export
+func writeJSON(create createFunc, name string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
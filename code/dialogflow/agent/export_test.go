@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSaveEntitiesRemovesStale(t *testing.T) {
+	dir := t.TempDir()
+	meta := Meta{Language: "en", SupportedLanguages: []string{"fr"}}
+	both := map[string][]Entry{"en": {NewEntry("rum")}, "fr": {NewEntry("rhum")}}
+	a := &Agent{Meta: meta, Entities: []*Entity{
+		{Name: "alcohol", Entries: both},
+		{Name: "glass", Entries: both},
+	}}
+	if err := a.SaveEntities(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a = &Agent{Meta: meta, Entities: []*Entity{
+		{Name: "alcohol", Entries: map[string][]Entry{"en": {NewEntry("rum")}}},
+	}}
+	if err := a.SaveEntities(dir); err != nil {
+		t.Fatal(err)
+	}
+	fis, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, fi := range fis {
+		got = append(got, fi.Name())
+	}
+	sort.Strings(got)
+	want := []string{"README", "alcohol.json", "alcohol_entries_en.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
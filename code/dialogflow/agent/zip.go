@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const (
+	userSaysInfix = "_usersays_"
+	entriesInfix  = "_entries_"
+)
+
+// LoadZip reads an agent export ZIP as produced by the Dialogflow console
+func LoadZip(p string) (*Agent, error) {
+	r, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return Load(&r.Reader)
+}
+
+// Load reads an agent export from a zip reader
+func Load(r *zip.Reader) (*Agent, error) {
+	return LoadFS(r)
+}
+
+// LoadFS reads an agent export from a file system using the export layout
+func LoadFS(fsys fs.FS) (*Agent, error) {
+	l := newLoader(fsys)
+	err := l.walk(func(dir, p, name string) error {
+		switch {
+		case dir == "" && name == "agent":
+			return readJSON(fsys, p, &l.agent.Meta)
+		case path.Base(dir) == "intents":
+			return l.intent(p, name)
+		case path.Base(dir) == "entities":
+			return l.entity(p, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.finish(), nil
+}
+
+// LoadEntities reads the entity files found at the root of the file system,
+// as written by SaveEntities
+func LoadEntities(fsys fs.FS) ([]*Entity, error) {
+	l := newLoader(fsys)
+	err := l.walk(func(dir, p, name string) error {
+		if dir != "" {
+			return nil
+		}
+		return l.entity(p, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.finish().Entities, nil
+}
+
+// loader gathers the files of an export, the training phrases and entries
+// being stored in separate files
+type loader struct {
+	fsys     fs.FS
+	agent    *Agent
+	intents  map[string]*Intent
+	entities map[string]*Entity
+	userSays map[string]map[string][]UserSays
+	entries  map[string]map[string][]Entry
+}
+
+func newLoader(fsys fs.FS) *loader {
+	return &loader{
+		fsys:     fsys,
+		agent:    &Agent{},
+		intents:  make(map[string]*Intent),
+		entities: make(map[string]*Entity),
+		userSays: make(map[string]map[string][]UserSays),
+		entries:  make(map[string]map[string][]Entry),
+	}
+}
+
+// walk calls fn for every JSON file with its directory, path and name without
+// the extension
+func (l *loader) walk(fn func(dir, p, name string) error) error {
+	return fs.WalkDir(l.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		dir, base := path.Split(p)
+		if d.IsDir() || path.Ext(base) != ".json" {
+			return nil
+		}
+		if err = fn(dir, p, strings.TrimSuffix(base, ".json")); err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		return nil
+	})
+}
+
+func (l *loader) intent(p, name string) error {
+	if i := strings.LastIndex(name, userSaysInfix); i >= 0 {
+		var us []UserSays
+		if err := readJSON(l.fsys, p, &us); err != nil {
+			return err
+		}
+		if l.userSays[name[:i]] == nil {
+			l.userSays[name[:i]] = make(map[string][]UserSays)
+		}
+		l.userSays[name[:i]][name[i+len(userSaysInfix):]] = us
+		return nil
+	}
+	in := &Intent{}
+	if err := readJSON(l.fsys, p, in); err != nil {
+		return err
+	}
+	l.intents[name] = in
+	return nil
+}
+
+func (l *loader) entity(p, name string) error {
+	if i := strings.LastIndex(name, entriesInfix); i >= 0 {
+		var es []Entry
+		if err := readJSON(l.fsys, p, &es); err != nil {
+			return err
+		}
+		if l.entries[name[:i]] == nil {
+			l.entries[name[:i]] = make(map[string][]Entry)
+		}
+		l.entries[name[:i]][name[i+len(entriesInfix):]] = es
+		return nil
+	}
+	e := &Entity{}
+	if err := readJSON(l.fsys, p, e); err != nil {
+		return err
+	}
+	l.entities[name] = e
+	return nil
+}
+
+// finish attaches the training phrases and entries to their intent and entity
+func (l *loader) finish() *Agent {
+	for file, in := range l.intents {
+		in.UserSays = l.userSays[file]
+		l.agent.Intents = append(l.agent.Intents, in)
+	}
+	for file, e := range l.entities {
+		e.Entries = l.entries[file]
+		l.agent.Entities = append(l.agent.Entities, e)
+	}
+	sortAgent(l.agent)
+	return l.agent
+}
+
+func readJSON(fsys fs.FS, p string, v interface{}) error {
+	b, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,82 @@
+package agent_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+	"github.com/Depado/articles/code/dialogflow/definition"
+)
+
+// comparable returns a copy of the intent whose messages don't depend on
+// their JSON encoding, the speech being indented and the type decoded as a
+// float64 once read
+func comparable(t *testing.T, in *agent.Intent) *agent.Intent {
+	t.Helper()
+	c := *in
+	c.Responses = append([]agent.Response{}, in.Responses...)
+	for i := range c.Responses {
+		ms := make([]agent.Message, len(c.Responses[i].Messages))
+		for j, m := range c.Responses[i].Messages {
+			var b bytes.Buffer
+			if err := json.Compact(&b, m.Speech); err != nil {
+				t.Fatal(err)
+			}
+			ms[j] = agent.Message{Type: fmt.Sprint(m.Type), Lang: m.Lang, Speech: b.Bytes()}
+		}
+		c.Responses[i].Messages = ms
+	}
+	return &c
+}
+
+func TestZipRoundTrip(t *testing.T) {
+	a, err := definition.Agent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := a.WriteZip(&b); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := agent.Load(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.Meta, a.Meta) {
+		t.Errorf("got meta %+v, expected %+v", got.Meta, a.Meta)
+	}
+	if len(got.Intents) != len(a.Intents) {
+		t.Fatalf("got %d intents, expected %d", len(got.Intents), len(a.Intents))
+	}
+	for i, in := range a.Intents {
+		if g, w := comparable(t, got.Intents[i]), comparable(t, in); !reflect.DeepEqual(g, w) {
+			t.Errorf("intent %s differs:\ngot      %+v\nexpected %+v", in.Name, g, w)
+		}
+	}
+	if len(got.Entities) != len(a.Entities) {
+		t.Fatalf("got %d entities, expected %d", len(got.Entities), len(a.Entities))
+	}
+	for i, e := range a.Entities {
+		if !reflect.DeepEqual(got.Entities[i], e) {
+			t.Errorf("entity %s differs:\ngot      %+v\nexpected %+v", e.Name, got.Entities[i], e)
+		}
+	}
+
+	// Writing the loaded agent gives the same archive
+	var again bytes.Buffer
+	if err := got.WriteZip(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), b.Bytes()) {
+		t.Error("the archive of the loaded agent differs")
+	}
+}
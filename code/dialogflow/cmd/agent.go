@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+	"github.com/Depado/articles/code/dialogflow/definition"
+	"github.com/Depado/articles/code/dialogflow/webhook"
+)
+
+var agentOutput string
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Build and check the Dialogflow agent defined in the definition package",
+}
+
+var agentBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Generate the agent export ZIP to import in the Dialogflow console",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := definition.Agent()
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't load agent")
+		}
+		if err = checkAgent(a); err != nil {
+			logrus.WithError(err).Fatal("Couldn't build agent")
+		}
+		if err = a.SaveZip(agentOutput); err != nil {
+			logrus.WithError(err).Fatal("Couldn't write agent")
+		}
+		fmt.Printf("%d intents and %d entities written to %s\n", len(a.Intents), len(a.Entities), agentOutput)
+	},
+}
+
+var agentCheckCmd = &cobra.Command{
+	Use:   "check [agent.zip]",
+	Short: "Check that the agent actions and the webhook handlers match",
+	Long: `Validates the agent and checks that every action of the intents using the
+webhook has a handler and that every handler is used by an intent. The agent
+defined in the definition package is checked unless an export is given.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := loadAgent(args)
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't load agent")
+		}
+		if err = checkAgent(a); err != nil {
+			logrus.WithError(err).Fatal("Check failed")
+		}
+		fmt.Printf("ok: %d actions handled\n", len(a.Actions()))
+	},
+}
+
+func init() {
+	agentBuildCmd.Flags().StringVarP(&agentOutput, "output", "o", "agent.zip", "path of the generated export")
+	agentCmd.AddCommand(agentBuildCmd, agentCheckCmd)
+}
+
+// loadAgent returns the agent of the export given as argument or the one
+// defined in the definition package
+func loadAgent(args []string) (*agent.Agent, error) {
+	if len(args) > 0 {
+		return agent.LoadZip(args[0])
+	}
+	return definition.Agent()
+}
+
+// checkAgent validates the agent and compares its actions with the ones
+// registered by the webhook
+func checkAgent(a *agent.Agent) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	return agent.CheckActions(a, webhook.New(nil, webhook.Options{}).Actions())
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/definition"
+	"github.com/Depado/articles/code/dialogflow/entitysync"
+)
+
+var (
+	entitiesDir    string
+	entitiesDrinks string
+	entitiesDryRun bool
+)
+
+var entitiesCmd = &cobra.Command{
+	Use:   "entities",
+	Short: "Manage the agent entities",
+}
+
+var entitiesSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Generate the entities from the cocktail source and show what changed",
+	Long: `Fetches the categories, glasses, ingredients and alcoholic flags from the
+cocktail API, generates the drink-type, glass, ingredient and alcohol entities
+with their synonyms and writes them in the entities directory after printing
+the differences with the previous version.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := syncEntities(); err != nil {
+			logrus.WithError(err).Fatal("Couldn't sync entities")
+		}
+	},
+}
+
+func init() {
+	entitiesSyncCmd.Flags().StringVar(&entitiesDir, "dir", "", "directory of the generated entities, "+definition.EntitiesDir+" in the module root found from the working directory if empty")
+	entitiesSyncCmd.Flags().StringVar(&entitiesDrinks, "drinks", "", "read the values from a drinks file instead of the cocktail API")
+	entitiesSyncCmd.Flags().BoolVar(&entitiesDryRun, "dry-run", false, "only print the differences")
+	entitiesCmd.AddCommand(entitiesSyncCmd)
+}
+
+func syncEntities() error {
+	dir := entitiesDir
+	if dir == "" {
+		root, err := definition.FindRoot(".")
+		if err != nil {
+			return fmt.Errorf("couldn't find the entities directory, use --dir: %v", err)
+		}
+		dir = filepath.Join(root, filepath.FromSlash(definition.EntitiesDir))
+	}
+
+	var l cocktail.Lister = &cocktail.C
+	if entitiesDrinks != "" {
+		ds, err := cocktail.LoadDrinks(entitiesDrinks)
+		if err != nil {
+			return err
+		}
+		l = ds
+	}
+
+	langs := append([]string{definition.Language}, definition.French)
+	es, err := entitysync.Generate(context.Background(), l, langs)
+	if err != nil {
+		return err
+	}
+
+	var old []*agent.Entity
+	if _, err = os.Stat(dir); err == nil {
+		if old, err = agent.LoadEntities(os.DirFS(dir)); err != nil {
+			return fmt.Errorf("couldn't load previous entities: %v", err)
+		}
+	}
+
+	cs := entitysync.Diff(old, es)
+	for _, c := range cs {
+		fmt.Println(c)
+	}
+	if len(cs) == 0 {
+		fmt.Println("no change")
+		return nil
+	}
+	if entitiesDryRun {
+		return nil
+	}
+	a := &agent.Agent{Meta: agent.Meta{Language: definition.Language, SupportedLanguages: []string{definition.French}}, Entities: es}
+	return a.SaveEntities(dir)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/replay"
+)
+
+var (
+	update  bool
+	backend string
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [dir]",
+	Short: "Replay recorded conversations and compare them with golden files",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "testdata/replay"
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		os.Exit(runReplay(dir, update, backend))
+	},
+}
+
+func init() {
+	replayCmd.Flags().BoolVar(&update, "update", false, "update the golden files instead of comparing")
+	replayCmd.Flags().StringVar(&backend, "backend", "all", "decoding backend to replay with, one of "+strings.Join(fulfillment.Codecs(), ", ")+" or all")
+}
+
+// runReplay replays the fixtures with every requested backend. All the
+// backends share the same golden files, so when updating only the first one
+// writes them and the others are compared with the result.
+func runReplay(dir string, update bool, backend string) int {
+	gin.SetMode(gin.ReleaseMode)
+
+	names := []string{backend}
+	if backend == "all" {
+		names = fulfillment.Codecs()
+	}
+
+	code := 0
+	for i, n := range names {
+		codec, err := fulfillment.CodecFor(n)
+		if err != nil {
+			logrus.WithError(err).Error("Invalid backend")
+			return 1
+		}
+		upd := update && i == 0
+		r, err := replay.NewRunner(dir, upd, codec)
+		if err != nil {
+			logrus.WithError(err).Error("Couldn't create replay runner")
+			return 1
+		}
+		rs, err := r.Run(dir)
+		if err != nil {
+			logrus.WithError(err).Error("Couldn't load fixtures")
+			return 1
+		}
+
+		for _, res := range rs {
+			switch {
+			case res.Err != nil:
+				fmt.Printf("FAIL %s [%s]: %v\n", res.Name, n, res.Err)
+				code = 1
+			case res.Diff != "":
+				fmt.Printf("FAIL %s [%s]\n%s", res.Name, n, res.Diff)
+				code = 1
+			case upd:
+				fmt.Printf("UPDT %s [%s]\n", res.Name, n)
+			default:
+				fmt.Printf("ok   %s [%s]\n", res.Name, n)
+			}
+		}
+	}
+	return code
+}
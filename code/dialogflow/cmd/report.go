@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Depado/articles/code/dialogflow/store"
+)
+
+var (
+	reportDays int
+	reportTop  int
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Print analytics about the webhook calls",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := report(); err != nil {
+			logrus.WithError(err).Fatal("Couldn't generate report")
+		}
+	},
+}
+
+func init() {
+	reportCmd.Flags().IntVar(&reportDays, "days", 7, "number of days covered by the report")
+	reportCmd.Flags().IntVar(&reportTop, "top", 10, "number of unknown actions to show")
+}
+
+// label returns the action name, requests that couldn't be decoded having no
+// action at all
+func label(action string) string {
+	if action == "" {
+		return "(none)"
+	}
+	return action
+}
+
+func report() error {
+	dsn := viper.GetString("db.dsn")
+	if dsn == "" {
+		return fmt.Errorf("no database configured, set db.dsn")
+	}
+	st, err := store.Open(viper.GetString("db.dialect"), dsn)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	y, m, d := time.Now().AddDate(0, 0, -reportDays+1).Date()
+	since := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	dcs, err := st.DailyCounts(since)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tw, "Calls per day since %s\n\nDAY\tACTION\tCOUNT\n", since.Format("2006-01-02"))
+	for _, dc := range dcs {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", dc.Day, label(dc.Action), dc.Count)
+	}
+
+	frs, err := st.FailureRates(since)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tw, "\nFailure rates\n\nACTION\tTOTAL\tFAILURES\tRATE\n")
+	for _, fr := range frs {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\n", label(fr.Action), fr.Total, fr.Failures, fr.Rate*100)
+	}
+
+	acs, err := st.TopUnknownActions(since, reportTop)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tw, "\nTop unknown actions\n\nACTION\tCOUNT\n")
+	for _, ac := range acs {
+		fmt.Fprintf(tw, "%s\t%d\n", ac.Action, ac.Count)
+	}
+	return tw.Flush()
+}
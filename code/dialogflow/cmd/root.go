@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"strings"
+
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "cocktail-webhook",
+	Short: "Dialogflow webhook answering cocktail related questions",
+	Long:  "Dialogflow fulfillment webhook backed by the cocktail database.",
+}
+
+// Execute executes the commands
+func Execute() {
+	rootCmd.AddCommand(serveCmd, replayCmd, reportCmd, simulateCmd, agentCmd, entitiesCmd)
+	if err := rootCmd.Execute(); err != nil {
+		logrus.WithError(err).Fatal()
+	}
+}
+
+func init() {
+	cobra.OnInitialize(initialize)
+
+	// Global flags
+	rootCmd.PersistentFlags().String("conf", "", "path to the configuration file")
+	rootCmd.PersistentFlags().String("log.level", "info", "one of debug, info, warn, error or fatal")
+	rootCmd.PersistentFlags().String("log.format", "text", "one of text or json")
+	rootCmd.PersistentFlags().String("db.dialect", "sqlite3", "gorm dialect of the database")
+	rootCmd.PersistentFlags().String("db.dsn", "", "data source name of the database keeping favorites, history and analytics")
+
+	// Flag binding
+	viper.BindPFlags(rootCmd.PersistentFlags())
+}
+
+func initialize() {
+	// Environment variables, server.addr can be set with SERVER_ADDR
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
+	if viper.GetString("conf") != "" {
+		viper.SetConfigFile(viper.GetString("conf"))
+	} else {
+		viper.SetConfigName("conf")
+		viper.AddConfigPath(".")
+		viper.AddConfigPath("/config/")
+	}
+
+	// Configuration file
+	if err := viper.ReadInConfig(); err != nil {
+		logrus.Debug("No configuration file found")
+	}
+
+	lvl := viper.GetString("log.level")
+	l, err := logrus.ParseLevel(lvl)
+	if err != nil {
+		logrus.WithField("level", lvl).Warn("Invalid log level, fallback to 'info'")
+	} else {
+		logrus.SetLevel(l)
+	}
+	switch f := viper.GetString("log.format"); f {
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	case "text":
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	default:
+		logrus.WithField("format", f).Warn("Invalid log format, fallback to 'text'")
+	}
+}
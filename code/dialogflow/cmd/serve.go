@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+	"github.com/Depado/articles/code/dialogflow/store"
+	"github.com/Depado/articles/code/dialogflow/webhook"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start the webhook server",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := serve(); err != nil {
+			logrus.WithError(err).Fatal("Couldn't run server")
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("server.addr", "127.0.0.1:8001", "address the server listens on")
+	serveCmd.Flags().String("server.tls.cert", "", "path to the TLS certificate, enables TLS along with the key")
+	serveCmd.Flags().String("server.tls.key", "", "path to the TLS private key")
+	serveCmd.Flags().Duration("server.timeout.read", 10*time.Second, "maximum duration for reading the entire request")
+	serveCmd.Flags().Duration("server.timeout.write", 10*time.Second, "maximum duration before timing out writes of the response")
+	serveCmd.Flags().Duration("server.timeout.idle", 60*time.Second, "maximum amount of time to wait for the next request on keep-alive connections")
+	serveCmd.Flags().Duration("server.timeout.drain", 5*time.Second, "duration the readiness probe fails before the server stops accepting connections on shutdown")
+	serveCmd.Flags().Duration("server.timeout.shutdown", 15*time.Second, "maximum duration to wait for in-flight requests on shutdown")
+	serveCmd.Flags().Duration("webhook.deadline", webhook.DefaultDeadline, "maximum duration of an action before a fallback response is sent")
+	serveCmd.Flags().Bool("webhook.cache-late", false, "keep the result of late actions for the next turn of the session")
+	serveCmd.Flags().String("webhook.backend", fulfillment.LeboncoinCodec, "library decoding the Dialogflow ES requests, one of "+strings.Join(fulfillment.Codecs(), ", "))
+	serveCmd.Flags().String("i18n.fallback", i18n.DefaultFallback, "locale used when the request language isn't supported")
+	viper.BindPFlags(serveCmd.Flags())
+}
+
+// webhookOptions builds the webhook options from the configuration. Per action
+// deadlines can only be set in the configuration file, in the
+// webhook.deadlines map (for example "random: 2s"), and so can the Dialogflow
+// CX transitions in the webhook.transitions map, which associates actions to
+// the full name of a page or flow.
+func webhookOptions() (webhook.Options, error) {
+	o := webhook.Options{
+		Deadline:    viper.GetDuration("webhook.deadline"),
+		CacheLate:   viper.GetBool("webhook.cache-late"),
+		Deadlines:   make(map[string]time.Duration),
+		Transitions: viper.GetStringMapString("webhook.transitions"),
+	}
+	c, err := i18n.Default()
+	if err != nil {
+		return o, fmt.Errorf("couldn't load message catalog: %v", err)
+	}
+	fb := viper.GetString("i18n.fallback")
+	if !c.Has(fb) {
+		return o, fmt.Errorf("fallback locale %q isn't shipped, available: %v", fb, c.Locales())
+	}
+	c.Fallback = fb
+	o.Catalog = c
+	if o.Codec, err = fulfillment.CodecFor(viper.GetString("webhook.backend")); err != nil {
+		return o, err
+	}
+	for action, v := range viper.GetStringMapString("webhook.deadlines") {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return o, fmt.Errorf("invalid deadline for action %s: %v", action, err)
+		}
+		o.Deadlines[action] = d
+	}
+	return o, nil
+}
+
+// accessLog is a gin middleware logging requests with logrus so that the
+// access logs follow the configured log format
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	logrus.WithFields(logrus.Fields{
+		"method":  c.Request.Method,
+		"path":    c.Request.URL.Path,
+		"status":  c.Writer.Status(),
+		"latency": time.Since(start),
+		"ip":      c.ClientIP(),
+	}).Debug("Request")
+}
+
+func serve() error {
+	cert := viper.GetString("server.tls.cert")
+	key := viper.GetString("server.tls.key")
+	if (cert == "") != (key == "") {
+		return fmt.Errorf("server.tls.cert and server.tls.key must be set together")
+	}
+	if logrus.GetLevel() < logrus.DebugLevel {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	o, err := webhookOptions()
+	if err != nil {
+		return err
+	}
+	if dsn := viper.GetString("db.dsn"); dsn != "" {
+		st, err := store.Open(viper.GetString("db.dialect"), dsn)
+		if err != nil {
+			return fmt.Errorf("couldn't open database: %v", err)
+		}
+		defer st.Close()
+		o.Store = st
+	} else {
+		logrus.Warn("No database configured, favorites, history and analytics are disabled")
+	}
+	w := webhook.New(&cocktail.C, o)
+	r := gin.New()
+	r.Use(gin.Recovery(), accessLog)
+	w.Register(r)
+
+	srv := &http.Server{
+		Addr:         viper.GetString("server.addr"),
+		Handler:      r,
+		ReadTimeout:  viper.GetDuration("server.timeout.read"),
+		WriteTimeout: viper.GetDuration("server.timeout.write"),
+		IdleTimeout:  viper.GetDuration("server.timeout.idle"),
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		clog := logrus.WithFields(logrus.Fields{"addr": srv.Addr, "tls": cert != ""})
+		clog.Info("Starting server")
+		var err error
+		if cert != "" {
+			err = srv.ListenAndServeTLS(cert, key)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
+			errc <- err
+		}
+		close(errc)
+	}()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	select {
+	case err := <-errc:
+		return err
+	case s := <-sigc:
+		logrus.WithField("signal", s.String()).Info("Shutting down, draining in-flight requests")
+	}
+
+	w.Drain(viper.GetDuration("server.timeout.drain"))
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("server.timeout.shutdown"))
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	logrus.Info("Server stopped")
+	return <-errc
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Depado/articles/code/dialogflow/simulator"
+)
+
+var (
+	simulateURL  string
+	simulateLang string
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate [agent.zip]",
+	Short: "Chat with an exported agent locally, without Dialogflow",
+	Long: `Loads an agent export, or the agent of the definition package when none is
+given, matches the input against its training phrases and entities, and sends
+the webhook requests Dialogflow would send to the webhook. Type :contexts to
+list the active contexts, :reset to clear them and :quit to exit.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := simulate(args); err != nil {
+			logrus.WithError(err).Fatal("Couldn't run simulator")
+		}
+	},
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulateURL, "url", "http://127.0.0.1:8001/webhook", "webhook URL, empty to only use the static responses")
+	simulateCmd.Flags().StringVar(&simulateLang, "lang", "", "language of the conversation, defaults to the agent language")
+}
+
+func simulate(args []string) error {
+	a, err := loadAgent(args)
+	if err != nil {
+		return fmt.Errorf("couldn't load agent: %v", err)
+	}
+	s := simulator.NewSession(a, simulateLang, simulateURL)
+	fmt.Printf("%d intents, %d entities, language %s\n", len(a.Intents), len(a.Entities), s.Lang)
+	return s.REPL(context.Background(), os.Stdin, os.Stdout)
+}
@@ -0,0 +1,110 @@
+package cocktail
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Ingredient is a single ingredient of a drink along with its measure
+type Ingredient struct {
+	Name    string
+	Measure string
+}
+
+// Ingredients returns the non-empty ingredients of the drink in order
+func (d *FullDrink) Ingredients() []Ingredient {
+	names := []string{
+		d.StrIngredient1, d.StrIngredient2, d.StrIngredient3, d.StrIngredient4, d.StrIngredient5,
+		d.StrIngredient6, d.StrIngredient7, d.StrIngredient8, d.StrIngredient9, d.StrIngredient10,
+		d.StrIngredient11, d.StrIngredient12, d.StrIngredient13, d.StrIngredient14, d.StrIngredient15,
+	}
+	measures := []string{
+		d.StrMeasure1, d.StrMeasure2, d.StrMeasure3, d.StrMeasure4, d.StrMeasure5,
+		d.StrMeasure6, d.StrMeasure7, d.StrMeasure8, d.StrMeasure9, d.StrMeasure10,
+		d.StrMeasure11, d.StrMeasure12, d.StrMeasure13, d.StrMeasure14, d.StrMeasure15,
+	}
+
+	var is []Ingredient
+	for i, n := range names {
+		if n = strings.TrimSpace(n); n == "" {
+			continue
+		}
+		is = append(is, Ingredient{Name: n, Measure: strings.TrimSpace(measures[i])})
+	}
+	return is
+}
+
+// Steps splits the instructions of the drink into sentences
+func (d *FullDrink) Steps() []string {
+	return SplitSentences(d.StrInstructions)
+}
+
+// SplitSentences splits a text into sentences. Lines are always split, then
+// each line is split on sentence terminators followed by a space and an upper
+// case letter, which is how the cocktail instructions are written. Step
+// numbers such as "1." are dropped.
+func SplitSentences(s string) []string {
+	var out []string
+	for _, l := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		out = append(out, splitLine(l)...)
+	}
+	return out
+}
+
+func splitLine(l string) []string {
+	var out []string
+	rs := []rune(strings.TrimSpace(l))
+	add := func(st string) {
+		st = strings.TrimSpace(st)
+		// Step numbers such as "1)" aren't sentence terminators and remain at
+		// the start of the sentence
+		if w := nextWord([]rune(st)); w != st && isStepNumber(w) {
+			st = strings.TrimSpace(st[len(w):])
+		}
+		if st != "" && !isStepNumber(st) {
+			out = append(out, st)
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != '.' && rs[i] != '!' && rs[i] != '?' {
+			continue
+		}
+		j := i + 1
+		for j < len(rs) && unicode.IsSpace(rs[j]) {
+			j++
+		}
+		if j == len(rs) || (j > i+1 && (unicode.IsUpper(rs[j]) || isStepNumber(nextWord(rs[j:])) || isStepNumber(string(rs[start:i+1])))) {
+			add(string(rs[start : i+1]))
+			start = j
+			i = j - 1
+		}
+	}
+	add(string(rs[start:]))
+	return out
+}
+
+// nextWord returns the first word of rs
+func nextWord(rs []rune) string {
+	for i, r := range rs {
+		if unicode.IsSpace(r) {
+			return string(rs[:i])
+		}
+	}
+	return string(rs)
+}
+
+// isStepNumber returns true for strings such as "1." or "2)"
+func isStepNumber(s string) bool {
+	s = strings.TrimRight(strings.TrimSpace(s), ".)")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
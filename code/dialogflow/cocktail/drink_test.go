@@ -0,0 +1,112 @@
+package cocktail
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			"margarita",
+			"Rub the rim of the glass with the lime slice to make the salt stick to it. Take care to moisten only the outer rim and sprinkle the salt on it. The salt should present to the lips of the imbiber and never mix into the cocktail. Shake the other ingredients with ice, then carefully pour into the glass.",
+			[]string{
+				"Rub the rim of the glass with the lime slice to make the salt stick to it.",
+				"Take care to moisten only the outer rim and sprinkle the salt on it.",
+				"The salt should present to the lips of the imbiber and never mix into the cocktail.",
+				"Shake the other ingredients with ice, then carefully pour into the glass.",
+			},
+		},
+		{
+			"single sentence without terminator",
+			"Stir in mixing glass with ice and strain",
+			[]string{"Stir in mixing glass with ice and strain"},
+		},
+		{
+			"ellipsis",
+			"Boil 3 cups of water then add jello. Mix jello and water until jello is completely disolved. Then, eat away...",
+			[]string{
+				"Boil 3 cups of water then add jello.",
+				"Mix jello and water until jello is completely disolved.",
+				"Then, eat away...",
+			},
+		},
+		{
+			"abbreviation ending a sentence",
+			"Pour 1 oz. Add the cream slowly over the back of a spoon.",
+			[]string{"Pour 1 oz.", "Add the cream slowly over the back of a spoon."},
+		},
+		{
+			"abbreviation inside a sentence",
+			"Pour 1 oz. of vodka and 2 oz. of orange juice over ice.",
+			[]string{"Pour 1 oz. of vodka and 2 oz. of orange juice over ice."},
+		},
+		{
+			"decimal",
+			"Add 1.5 oz of rum! Stir well?",
+			[]string{"Add 1.5 oz of rum!", "Stir well?"},
+		},
+		{
+			"numbered lines",
+			"1. Fill a glass with ice.\r\n2. Add the vodka.\r\n\r\n3. Top with orange juice",
+			[]string{"Fill a glass with ice.", "Add the vodka.", "Top with orange juice"},
+		},
+		{
+			"numbered steps on a single line",
+			"1) Rim the glass with sugar. 2) shake the rest with ice. 3. strain into the glass.",
+			[]string{"Rim the glass with sugar.", "shake the rest with ice.", "strain into the glass."},
+		},
+		{
+			"lines without terminator",
+			"Muddle the mint\nAdd the rum\n",
+			[]string{"Muddle the mint", "Add the rum"},
+		},
+		{"empty", " \r\n ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSentences(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsStepNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"1.", true},
+		{"12)", true},
+		{" 3 ", true},
+		{"4.)", true},
+		{".", false},
+		{"", false},
+		{"1.5", false},
+		{"oz.", false},
+		{"1a.", false},
+	}
+	for _, tt := range tests {
+		if got := isStepNumber(tt.in); got != tt.want {
+			t.Errorf("isStepNumber(%q) = %v, expected %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSteps(t *testing.T) {
+	d := &FullDrink{StrInstructions: "Muddle mint leaves with sugar and lime juice. Add a splash of soda water and fill the glass with cracked ice. Pour the rum and top with soda water. Garnish and serve with straw."}
+	want := []string{
+		"Muddle mint leaves with sugar and lime juice.",
+		"Add a splash of soda water and fill the glass with cracked ice.",
+		"Pour the rum and top with soda water.",
+		"Garnish and serve with straw.",
+	}
+	if got := d.Steps(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
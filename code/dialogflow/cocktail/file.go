@@ -0,0 +1,57 @@
+package cocktail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Drinks is a fixed list of drinks, usually loaded from a file with
+// LoadDrinks, which can be used as a Lister instead of the cocktail API
+type Drinks []*FullDrink
+
+// LoadDrinks reads the drinks of a JSON file using the same format as the
+// cocktail API (a FullDrinkList)
+func LoadDrinks(path string) (Drinks, error) {
+	var dl FullDrinkList
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &dl); err != nil {
+		return nil, fmt.Errorf("couldn't decode %s: %v", path, err)
+	}
+	return dl.Drinks, nil
+}
+
+// List returns the values of the list found in the drinks, in order of
+// appearance
+func (ds Drinks) List(ctx context.Context, l List) ([]string, error) {
+	seen := make(map[string]bool)
+	var vs []string
+	add := func(v string) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			vs = append(vs, v)
+		}
+	}
+	for _, d := range ds {
+		switch l {
+		case Categories:
+			add(d.StrCategory)
+		case Glasses:
+			add(d.StrGlass)
+		case Alcoholic:
+			add(d.StrAlcoholic)
+		case Ingredients:
+			for _, i := range d.Ingredients() {
+				add(i.Name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown list %q", l)
+		}
+	}
+	return vs, nil
+}
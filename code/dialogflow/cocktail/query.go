@@ -2,10 +2,13 @@ package cocktail
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -27,7 +30,7 @@ var C = Client{
 	},
 }
 
-func (c *Client) newRequest(method, path string, body interface{}) (*http.Request, error) {
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
 	rel := &url.URL{Path: path}
 	u := c.BaseURL.ResolveReference(rel)
 
@@ -40,7 +43,7 @@ func (c *Client) newRequest(method, path string, body interface{}) (*http.Reques
 		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
 	if err != nil {
 		return nil, err
 	}
@@ -66,19 +69,105 @@ func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
 }
 
 // GetRandomDrink returns a single random FullDrink object
-func (c *Client) GetRandomDrink() (*FullDrink, error) {
+func (c *Client) GetRandomDrink(ctx context.Context) (*FullDrink, error) {
 	var err error
 	var req *http.Request
 	var d *FullDrink
 	var ds *FullDrinkList
 
-	if req, err = c.newRequest("GET", "random.php", nil); err != nil {
+	if req, err = c.newRequest(ctx, "GET", "random.php", nil); err != nil {
 		return d, err
 	}
 
 	_, err = c.do(req, &ds)
-	if len(ds.Drinks) > 0 {
+	if ds != nil && len(ds.Drinks) > 0 {
 		d = ds.Drinks[0]
 	}
 	return d, err
 }
+
+// LookupDrink returns the FullDrink with the given ID, or ErrNotFound
+func (c *Client) LookupDrink(ctx context.Context, id string) (*FullDrink, error) {
+	var err error
+	var req *http.Request
+	var ds *FullDrinkList
+
+	if req, err = c.newRequest(ctx, "GET", "lookup.php", nil); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = url.Values{"i": []string{id}}.Encode()
+
+	if _, err = c.do(req, &ds); err != nil {
+		return nil, err
+	}
+	if ds == nil || len(ds.Drinks) == 0 {
+		return nil, ErrNotFound
+	}
+	return ds.Drinks[0], nil
+}
+
+// SearchDrinks returns the drinks whose name contains the given string
+func (c *Client) SearchDrinks(ctx context.Context, name string) ([]*FullDrink, error) {
+	var err error
+	var req *http.Request
+	var ds *FullDrinkList
+
+	if req, err = c.newRequest(ctx, "GET", "search.php", nil); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = url.Values{"s": []string{name}}.Encode()
+
+	if _, err = c.do(req, &ds); err != nil || ds == nil {
+		return nil, err
+	}
+	return ds.Drinks, nil
+}
+
+// Ping checks that the cocktail API is reachable and answers properly
+func (c *Client) Ping(ctx context.Context) error {
+	var err error
+	var req *http.Request
+	var resp *http.Response
+	var l json.RawMessage
+
+	if req, err = c.newRequest(ctx, "GET", "list.php", nil); err != nil {
+		return err
+	}
+	req.URL.RawQuery = url.Values{"a": []string{"list"}}.Encode()
+
+	if resp, err = c.do(req, &l); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// List returns the values of the given list, for example all the categories
+func (c *Client) List(ctx context.Context, l List) ([]string, error) {
+	var err error
+	var req *http.Request
+	var ls struct {
+		Drinks []map[string]string `json:"drinks"`
+	}
+
+	if req, err = c.newRequest(ctx, "GET", "list.php", nil); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = url.Values{string(l): []string{"list"}}.Encode()
+
+	if _, err = c.do(req, &ls); err != nil {
+		return nil, err
+	}
+	// Every item is an object with a single key, strCategory for example
+	vs := make([]string, 0, len(ls.Drinks))
+	for _, d := range ls.Drinks {
+		for _, v := range d {
+			if v = strings.TrimSpace(v); v != "" {
+				vs = append(vs, v)
+			}
+		}
+	}
+	return vs, nil
+}
@@ -0,0 +1,36 @@
+package cocktail
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when looking up a drink that doesn't exist
+var ErrNotFound = errors.New("drink not found")
+
+// Source is anything able to provide drinks. Client is the main implementation
+// but this allows to swap it with a fake one when replaying conversations.
+type Source interface {
+	GetRandomDrink(ctx context.Context) (*FullDrink, error)
+	LookupDrink(ctx context.Context, id string) (*FullDrink, error)
+	SearchDrinks(ctx context.Context, name string) ([]*FullDrink, error)
+	Ping(ctx context.Context) error
+}
+
+// List is one of the lists of values exposed by the cocktail API
+type List string
+
+// Lists exposed by the cocktail API, the values being the query parameter of
+// the list endpoint
+const (
+	Categories  List = "c"
+	Glasses     List = "g"
+	Ingredients List = "i"
+	Alcoholic   List = "a"
+)
+
+// Lister is anything able to provide the lists of values used to generate the
+// agent entities
+type Lister interface {
+	List(ctx context.Context, l List) ([]string, error)
+}
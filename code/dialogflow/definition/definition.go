@@ -0,0 +1,58 @@
+// Package definition holds the cocktail agent as code. The agent export
+// imported in the Dialogflow console is generated from these definitions with
+// the "agent build" command. The entities are generated from the cocktail
+// source with the "entities sync" command.
+package definition
+
+import (
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+const (
+	// Language is the default language of the agent
+	Language = "en"
+	// French is the other language supported by the agent
+	French = "fr"
+)
+
+// Agent returns the complete cocktail agent
+func Agent() (*agent.Agent, error) {
+	es, err := Entities()
+	if err != nil {
+		return nil, err
+	}
+	return &agent.Agent{
+		Meta: agent.Meta{
+			Description:        "Answers cocktail related questions using the cocktail database",
+			Language:           Language,
+			SupportedLanguages: []string{French},
+			Webhook:            agent.Webhook{Available: true},
+			DefaultTimezone:    "Europe/Paris",
+		},
+		Intents:  Intents(),
+		Entities: es,
+	}, nil
+}
+
+// intent returns an intent using the webhook for the given action
+func intent(name, action string, en, fr []agent.UserSays, params ...agent.Parameter) *agent.Intent {
+	return &agent.Intent{
+		Name:        name,
+		Priority:    500000,
+		WebhookUsed: true,
+		Responses:   []agent.Response{{Action: action, Parameters: params}},
+		UserSays:    map[string][]agent.UserSays{Language: en, French: fr},
+	}
+}
+
+// withContexts sets the input contexts of the intent
+func withContexts(in *agent.Intent, contexts ...string) *agent.Intent {
+	in.Contexts = contexts
+	return in
+}
+
+// withOutput adds an output context to the intent
+func withOutput(in *agent.Intent, name string, lifespan int) *agent.Intent {
+	in.Responses[0].AffectedContexts = append(in.Responses[0].AffectedContexts, agent.AffectedContext{Name: name, Lifespan: lifespan})
+	return in
+}
@@ -0,0 +1,47 @@
+package definition
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+// EntitiesDir is the directory holding the entities generated by the
+// "entities sync" command, relative to the root of the module, see FindRoot
+const EntitiesDir = "definition/entities"
+
+// FindRoot returns the root of the module holding the agent definition, which
+// is dir or its closest parent containing the definition package sources
+func FindRoot(dir string) (string, error) {
+	d, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err = os.Stat(filepath.Join(d, "definition", "definition.go")); err == nil {
+			return d, nil
+		}
+		p := filepath.Dir(d)
+		if p == d {
+			return "", fmt.Errorf("no definition package found in %s or its parents", dir)
+		}
+		d = p
+	}
+}
+
+//go:embed entities/*.json
+var entities embed.FS
+
+// Entities returns the entities of the agent, generated from the cocktail
+// source and embedded in the binary
+func Entities() ([]*agent.Entity, error) {
+	sub, err := fs.Sub(entities, "entities")
+	if err != nil {
+		return nil, err
+	}
+	return agent.LoadEntities(sub)
+}
@@ -0,0 +1,171 @@
+package definition
+
+import (
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+// Contexts shared between the intents, the recipe one being set by the
+// webhook itself
+const (
+	searchFollowup = "search-followup"
+	recipe         = "recipe"
+)
+
+var (
+	t = agent.T
+	e = agent.E
+	p = agent.Phrase
+)
+
+// Intents returns the intents of the agent
+func Intents() []*agent.Intent {
+	alcohol := agent.NewParameter("alcohol", "@alcohol", false)
+	drinkType := agent.NewParameter("drink-type", "@drink-type", false)
+	ingredient := agent.NewParameter("ingredient", "@ingredient", false)
+	glass := agent.NewParameter("glass", "@glass", false)
+	name := agent.NewParameter("name", "@sys.any", false)
+
+	return []*agent.Intent{
+		welcome(),
+		fallback(),
+
+		withOutput(intent("search", "search",
+			[]agent.UserSays{
+				p(t("I want a cocktail with "), e("rum", "@ingredient", "ingredient")),
+				p(t("find me a "), e("shot", "@drink-type", "drink-type")),
+				p(t("give me an "), e("alcohol free", "@alcohol", "alcohol"), t(" "), e("cocktail", "@drink-type", "drink-type")),
+				p(t("what can I make with "), e("gin", "@ingredient", "ingredient")),
+				p(t("something served in a "), e("highball glass", "@glass", "glass")),
+			},
+			[]agent.UserSays{
+				p(t("je veux un cocktail avec du "), e("rhum", "@ingredient", "ingredient")),
+				p(t("trouve moi un "), e("shot", "@drink-type", "drink-type")),
+				p(t("un "), e("cocktail", "@drink-type", "drink-type"), t(" "), e("sans alcool", "@alcohol", "alcohol")),
+				p(t("que faire avec du "), e("gin", "@ingredient", "ingredient")),
+				p(t("quelque chose servi dans une "), e("flûte à champagne", "@glass", "glass")),
+			},
+			alcohol, drinkType, ingredient, glass,
+		), searchFollowup, 2),
+
+		withOutput(withContexts(intent("search - specify", "search.specify",
+			[]agent.UserSays{
+				p(t("with "), e("tequila", "@ingredient", "ingredient"), t(" instead")),
+				p(t("rather a "), e("punch", "@drink-type", "drink-type")),
+				p(t("what about a "), e("virgin", "@alcohol", "alcohol"), t(" one")),
+			},
+			[]agent.UserSays{
+				p(t("plutôt avec de la "), e("tequila", "@ingredient", "ingredient")),
+				p(t("plutôt un "), e("punch", "@drink-type", "drink-type")),
+				p(t("et avec du "), e("whisky", "@ingredient", "ingredient")),
+			},
+			alcohol, drinkType, ingredient, glass,
+		), searchFollowup), searchFollowup, 2),
+
+		intent("random", "random",
+			agent.Phrases("give me a random cocktail", "surprise me", "any cocktail", "I don't know what to drink"),
+			agent.Phrases("un cocktail au hasard", "surprends moi", "n'importe quel cocktail", "je ne sais pas quoi boire"),
+		),
+
+		intent("recipe.start", "recipe.start",
+			[]agent.UserSays{
+				p(t("how do I make a "), e("mojito", "@sys.any", "name")),
+				p(t("show me the recipe of the "), e("margarita", "@sys.any", "name")),
+				p(t("how do I make it")),
+				p(t("let's make it")),
+			},
+			[]agent.UserSays{
+				p(t("comment faire un "), e("mojito", "@sys.any", "name")),
+				p(t("montre moi la recette du "), e("margarita", "@sys.any", "name")),
+				p(t("comment on le fait")),
+				p(t("allons-y")),
+			},
+			name,
+		),
+		withContexts(intent("recipe.next", "recipe.next",
+			agent.Phrases("next", "next step", "done", "what's next"),
+			agent.Phrases("suivant", "étape suivante", "c'est fait", "et ensuite"),
+		), recipe),
+		withContexts(intent("recipe.repeat", "recipe.repeat",
+			agent.Phrases("repeat", "say that again", "what did you say"),
+			agent.Phrases("répète", "tu peux répéter", "qu'est-ce que tu as dit"),
+		), recipe),
+		withContexts(intent("recipe.previous", "recipe.previous",
+			agent.Phrases("previous", "previous step", "go back"),
+			agent.Phrases("précédent", "étape précédente", "reviens en arrière"),
+		), recipe),
+		withContexts(intent("recipe.restart", "recipe.restart",
+			agent.Phrases("start over", "from the beginning", "restart"),
+			agent.Phrases("recommence", "depuis le début", "on reprend"),
+		), recipe),
+
+		intent("favorites.add", "favorites.add",
+			[]agent.UserSays{
+				p(t("save it to my favorites")),
+				p(t("I love it")),
+				p(t("add the "), e("mojito", "@sys.any", "name"), t(" to my favorites")),
+			},
+			[]agent.UserSays{
+				p(t("ajoute le à mes favoris")),
+				p(t("j'adore")),
+				p(t("ajoute le "), e("mojito", "@sys.any", "name"), t(" à mes favoris")),
+			},
+			name,
+		),
+		intent("favorites.list", "favorites.list",
+			agent.Phrases("what are my favorites", "show my favorite cocktails", "list my favorites"),
+			agent.Phrases("quels sont mes favoris", "montre mes cocktails favoris", "liste mes favoris"),
+		),
+		intent("favorites.remove", "favorites.remove",
+			[]agent.UserSays{
+				p(t("remove it from my favorites")),
+				p(t("remove the "), e("mojito", "@sys.any", "name"), t(" from my favorites")),
+			},
+			[]agent.UserSays{
+				p(t("retire le de mes favoris")),
+				p(t("retire le "), e("mojito", "@sys.any", "name"), t(" de mes favoris")),
+			},
+			name,
+		),
+		intent("history.list", "history.list",
+			agent.Phrases("what did I look at", "show my history", "what were the last cocktails"),
+			agent.Phrases("qu'est-ce que j'ai regardé", "montre mon historique", "quels étaient les derniers cocktails"),
+		),
+	}
+}
+
+// welcome is the intent triggered when the conversation starts, answered
+// without the webhook
+func welcome() *agent.Intent {
+	return &agent.Intent{
+		Name:     "Default Welcome Intent",
+		Priority: 500000,
+		Events:   []agent.Event{{Name: "WELCOME"}},
+		Responses: []agent.Response{{
+			Action: "input.welcome",
+			Messages: []agent.Message{
+				agent.TextMessage(Language, "Hi! Ask me for a cocktail or for a recipe."),
+				agent.TextMessage(French, "Bonjour ! Demande-moi un cocktail ou une recette."),
+			},
+		}},
+		UserSays: map[string][]agent.UserSays{
+			Language: agent.Phrases("hi", "hello", "hey"),
+			French:   agent.Phrases("salut", "bonjour", "coucou"),
+		},
+	}
+}
+
+// fallback is the intent matched when nothing else does
+func fallback() *agent.Intent {
+	return &agent.Intent{
+		Name:           "Default Fallback Intent",
+		Priority:       500000,
+		FallbackIntent: true,
+		Responses: []agent.Response{{
+			Action: "input.unknown",
+			Messages: []agent.Message{
+				agent.TextMessage(Language, "Sorry, I didn't get that.", "Can you say that again?"),
+				agent.TextMessage(French, "Désolé, je n'ai pas compris.", "Tu peux répéter ?"),
+			},
+		}},
+	}
+}
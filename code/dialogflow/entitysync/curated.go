@@ -0,0 +1,71 @@
+package entitysync
+
+// curated holds the synonyms that can't be derived from the values, per
+// language and then per value. Values missing from the source are ignored.
+var curated = map[string]map[string][]string{
+	"en": {
+		"Alcoholic":        {"with alcohol", "alcohol", "boozy"},
+		"Non alcoholic":    {"without alcohol", "alcohol free", "virgin", "mocktail"},
+		"Optional alcohol": {"alcohol optional", "with or without alcohol"},
+		"Ordinary Drink":   {"drink", "regular drink"},
+		"Punch / Party Drink": {
+			"party drinks",
+		},
+		"Soft Drink": {"soda", "sodas"},
+		"Whiskey":    {"whisky"},
+	},
+	"fr": {
+		"Alcoholic":           {"alcoolisé", "avec alcool", "alcool"},
+		"Non alcoholic":       {"sans alcool", "non alcoolisé", "virgin"},
+		"Optional alcohol":    {"alcool optionnel", "avec ou sans alcool"},
+		"Ordinary Drink":      {"boisson", "boisson classique"},
+		"Punch / Party Drink": {"boisson de fête"},
+		"Beer":                {"bière", "bières"},
+		"Coffee / Tea":        {"café", "thé"},
+		"Cocoa":               {"chocolat chaud", "cacao"},
+		"Shake":               {"milkshake"},
+		"Soft Drink":          {"soda", "boisson gazeuse"},
+		"Homemade Liqueur":    {"liqueur maison"},
+		"Other / Unknown":     {"autre"},
+
+		"Cocktail glass":  {"verre à cocktail"},
+		"Shot glass":      {"verre à shot"},
+		"Wine Glass":      {"verre à vin"},
+		"Champagne flute": {"flûte à champagne"},
+		"Highball glass":  {"verre highball"},
+		"Coffee mug":      {"tasse"},
+		"Beer mug":        {"chope"},
+
+		"Rum":              {"rhum"},
+		"Light rum":        {"rhum blanc"},
+		"Dark rum":         {"rhum brun"},
+		"Spiced rum":       {"rhum épicé"},
+		"Whiskey":          {"whisky"},
+		"Lemon juice":      {"jus de citron"},
+		"Lime juice":       {"jus de citron vert"},
+		"Pineapple juice":  {"jus d'ananas"},
+		"Apple juice":      {"jus de pomme"},
+		"Cranberry juice":  {"jus de cranberry"},
+		"Grapefruit juice": {"jus de pamplemousse"},
+		"Tomato juice":     {"jus de tomate"},
+		"Sugar":            {"sucre"},
+		"Sugar syrup":      {"sirop de sucre"},
+		"Water":            {"eau"},
+		"Carbonated water": {"eau gazeuse"},
+		"Milk":             {"lait"},
+		"Heavy cream":      {"crème"},
+		"Coffee":           {"café"},
+		"Tea":              {"thé"},
+		"Egg":              {"oeuf"},
+		"Egg yolk":         {"jaune d'oeuf"},
+		"Lemon":            {"citron"},
+		"Lime":             {"citron vert"},
+		"Orange":           {"orange"},
+		"Red wine":         {"vin rouge"},
+		"Cider":            {"cidre"},
+		"Strawberries":     {"fraises"},
+		"Chocolate":        {"chocolat"},
+		"Ginger":           {"gingembre"},
+		"Grapes":           {"raisin"},
+	},
+}
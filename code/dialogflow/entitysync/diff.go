@@ -0,0 +1,150 @@
+package entitysync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+// Op is the kind of change
+type Op string
+
+// Kinds of change
+const (
+	Added   Op = "+"
+	Removed Op = "-"
+	Changed Op = "~"
+)
+
+// Change is a difference between two versions of the entities. Value is empty
+// when the whole entity was added or removed.
+type Change struct {
+	Entity  string
+	Lang    string
+	Value   string
+	Op      Op
+	Added   []string
+	Removed []string
+}
+
+func (c Change) String() string {
+	if c.Value == "" {
+		return fmt.Sprintf("%s %s", c.Op, c.Entity)
+	}
+	s := fmt.Sprintf("%s %s [%s] %s", c.Op, c.Entity, c.Lang, c.Value)
+	if c.Op != Changed {
+		return s
+	}
+	var ds []string
+	for _, a := range c.Added {
+		ds = append(ds, "+"+a)
+	}
+	for _, r := range c.Removed {
+		ds = append(ds, "-"+r)
+	}
+	return s + " (" + strings.Join(ds, ", ") + ")"
+}
+
+// Diff returns the changes needed to go from the old entities to the new ones
+func Diff(old, new []*agent.Entity) []Change {
+	var cs []Change
+	var names []string
+	olds := make(map[string]*agent.Entity, len(old))
+	for _, e := range old {
+		olds[e.Name] = e
+		names = append(names, e.Name)
+	}
+	news := make(map[string]*agent.Entity, len(new))
+	for _, e := range new {
+		news[e.Name] = e
+		names = append(names, e.Name)
+	}
+
+	for _, n := range unique(names) {
+		o, ok := olds[n]
+		if !ok {
+			cs = append(cs, Change{Entity: n, Op: Added})
+			continue
+		}
+		e, ok := news[n]
+		if !ok {
+			cs = append(cs, Change{Entity: n, Op: Removed})
+			continue
+		}
+		var langs []string
+		for l := range o.Entries {
+			langs = append(langs, l)
+		}
+		for l := range e.Entries {
+			langs = append(langs, l)
+		}
+		for _, lang := range unique(langs) {
+			cs = append(cs, diffEntries(n, lang, o.Entries[lang], e.Entries[lang])...)
+		}
+	}
+	return cs
+}
+
+// diffEntries compares the entries of an entity in a single language
+func diffEntries(entity, lang string, old, new []agent.Entry) []Change {
+	var cs []Change
+	var values []string
+	olds := make(map[string][]string, len(old))
+	for _, e := range old {
+		olds[e.Value] = e.Synonyms
+		values = append(values, e.Value)
+	}
+	news := make(map[string][]string, len(new))
+	for _, e := range new {
+		news[e.Value] = e.Synonyms
+		values = append(values, e.Value)
+	}
+
+	for _, v := range unique(values) {
+		o, inOld := olds[v]
+		n, inNew := news[v]
+		switch {
+		case !inOld:
+			cs = append(cs, Change{Entity: entity, Lang: lang, Value: v, Op: Added})
+		case !inNew:
+			cs = append(cs, Change{Entity: entity, Lang: lang, Value: v, Op: Removed})
+		default:
+			added, removed := minus(n, o), minus(o, n)
+			if len(added) > 0 || len(removed) > 0 {
+				cs = append(cs, Change{Entity: entity, Lang: lang, Value: v, Op: Changed, Added: added, Removed: removed})
+			}
+		}
+	}
+	return cs
+}
+
+// minus returns the elements of a that aren't in b
+func minus(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !in[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// unique returns the sorted values of ss without duplicates
+func unique(ss []string) []string {
+	seen := make(map[string]bool, len(ss))
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,59 @@
+package entitysync
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+func entity(name string, entries map[string][]agent.Entry) *agent.Entity {
+	return &agent.Entity{Name: name, Entries: entries}
+}
+
+func TestDiff(t *testing.T) {
+	glass := entity("glass", map[string][]agent.Entry{
+		"en": {agent.NewEntry("Highball glass", "highball"), agent.NewEntry("Cocktail glass", "cocktail glass")},
+		"fr": {agent.NewEntry("Highball glass", "verre highball")},
+	})
+	tests := []struct {
+		name     string
+		old, new []*agent.Entity
+		want     []string
+	}{
+		{"same", []*agent.Entity{glass}, []*agent.Entity{glass}, nil},
+		{"entity added", nil, []*agent.Entity{glass}, []string{"+ glass"}},
+		{"entity removed", []*agent.Entity{glass, entity("alcohol", nil)}, []*agent.Entity{glass}, []string{"- alcohol"}},
+		{
+			"entries",
+			[]*agent.Entity{glass},
+			[]*agent.Entity{entity("glass", map[string][]agent.Entry{
+				"en": {agent.NewEntry("Highball glass", "highball", "tall glass"), agent.NewEntry("Shot glass", "shot")},
+				"fr": {agent.NewEntry("Highball glass", "grand verre")},
+			})},
+			[]string{
+				"- glass [en] Cocktail glass",
+				"~ glass [en] Highball glass (+tall glass)",
+				"+ glass [en] Shot glass",
+				"~ glass [fr] Highball glass (+grand verre, -verre highball)",
+			},
+		},
+		{
+			"language removed",
+			[]*agent.Entity{glass},
+			[]*agent.Entity{entity("glass", map[string][]agent.Entry{"en": glass.Entries["en"]})},
+			[]string{"- glass [fr] Highball glass"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range Diff(tt.old, tt.new) {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Package entitysync generates the agent entities from the lists of values of
+// the cocktail source so that they don't have to be maintained by hand.
+package entitysync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+)
+
+// spec describes how an entity is generated from a list
+type spec struct {
+	Name   string
+	List   cocktail.List
+	Plural bool
+}
+
+var specs = []spec{
+	{Name: "alcohol", List: cocktail.Alcoholic},
+	{Name: "drink-type", List: cocktail.Categories, Plural: true},
+	{Name: "glass", List: cocktail.Glasses, Plural: true},
+	{Name: "ingredient", List: cocktail.Ingredients},
+}
+
+// Generate fetches the lists from the source and returns the entities with
+// their entries for each of the given languages, the first one being the
+// language of the source
+func Generate(ctx context.Context, l cocktail.Lister, langs []string) ([]*agent.Entity, error) {
+	es := make([]*agent.Entity, 0, len(specs))
+	for _, s := range specs {
+		vs, err := l.List(ctx, s.List)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't list %s: %v", s.Name, err)
+		}
+		if len(vs) == 0 {
+			return nil, fmt.Errorf("no value for %s", s.Name)
+		}
+		sort.Strings(vs)
+		e := &agent.Entity{
+			Name:          s.Name,
+			IsOverridable: true,
+			Entries:       make(map[string][]agent.Entry, len(langs)),
+		}
+		for _, lang := range langs {
+			entries := make([]agent.Entry, 0, len(vs))
+			for _, v := range vs {
+				entries = append(entries, agent.Entry{Value: v, Synonyms: synonyms(v, lang, s.Plural)})
+			}
+			e.Entries[lang] = entries
+		}
+		es = append(es, e)
+	}
+	return es, nil
+}
+
+// synonyms returns the synonyms of the value: the value itself, its lower case
+// variants, the parts of values such as "Coffee / Tea", their plurals if
+// needed and the curated synonyms of the language
+func synonyms(v, lang string, plural bool) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(s string) {
+		s = strings.Join(strings.Fields(s), " ")
+		if k := strings.ToLower(s); s != "" && !seen[k] {
+			seen[k] = true
+			out = append(out, s)
+		}
+	}
+
+	add(v)
+	lv := strings.ToLower(v)
+	variants := []string{lv, strings.Replace(lv, "-", " ", -1), strings.Replace(lv, "&", "and", -1)}
+	if parts := strings.Split(lv, "/"); len(parts) > 1 {
+		for _, p := range parts {
+			variants = append(variants, strings.TrimSpace(p))
+		}
+	}
+	for _, s := range variants {
+		add(s)
+		if plural && !strings.Contains(s, "/") {
+			add(pluralize(s))
+		}
+	}
+	for _, s := range curated[lang][v] {
+		add(s)
+	}
+	return out
+}
+
+// pluralize returns the English plural of the last word of s
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "s"):
+		return s
+	case strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"), strings.HasSuffix(s, "x"):
+		return s + "es"
+	case len(s) > 1 && strings.HasSuffix(s, "y") && !strings.ContainsAny(s[len(s)-2:len(s)-1], "aeiou"):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
@@ -0,0 +1,123 @@
+package entitysync
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+)
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"cocktail", "cocktails"},
+		{"party drink", "party drinks"},
+		{"punch", "punches"},
+		{"wash", "washes"},
+		{"box", "boxes"},
+		{"brandy", "brandies"},
+		{"day", "days"},
+		{"y", "ys"},
+		{"shots", "shots"},
+	}
+	for _, tt := range tests {
+		if got := pluralize(tt.in); got != tt.want {
+			t.Errorf("pluralize(%q) = %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSynonyms(t *testing.T) {
+	tests := []struct {
+		v      string
+		lang   string
+		plural bool
+		want   []string
+	}{
+		{"Rum", "en", false, []string{"Rum"}},
+		{"Rum", "fr", false, []string{"Rum", "rhum"}},
+		{"Cocktail", "en", true, []string{"Cocktail", "cocktails"}},
+		{"7-Up", "en", false, []string{"7-Up", "7 up"}},
+		{"Salt & pepper", "en", false, []string{"Salt & pepper", "salt and pepper"}},
+		{"Coffee / Tea", "fr", true, []string{"Coffee / Tea", "coffee", "coffees", "tea", "teas", "café", "thé"}},
+		{"Punch / Party Drink", "en", true, []string{"Punch / Party Drink", "punch", "punches", "party drink", "party drinks"}},
+		{"Whiskey", "en", false, []string{"Whiskey", "whisky"}},
+		{"Light  rum", "fr", false, []string{"Light rum"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v+"/"+tt.lang, func(t *testing.T) {
+			if got := synonyms(tt.v, tt.lang, tt.plural); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingLister fails to list the given list
+type failingLister struct {
+	cocktail.Lister
+	list cocktail.List
+}
+
+func (f failingLister) List(ctx context.Context, l cocktail.List) ([]string, error) {
+	if l == f.list {
+		return nil, errors.New("unavailable")
+	}
+	return f.Lister.List(ctx, l)
+}
+
+func TestGenerate(t *testing.T) {
+	ds := cocktail.Drinks{
+		{StrDrink: "Mojito", StrCategory: "Cocktail", StrGlass: "Highball glass", StrAlcoholic: "Alcoholic", StrIngredient1: "Light rum", StrIngredient2: "Lime"},
+		{StrDrink: "Lemonade", StrCategory: "Soft Drink", StrGlass: "Highball glass", StrAlcoholic: "Non alcoholic", StrIngredient1: "Lemon", StrIngredient2: "Sugar"},
+	}
+	es, err := Generate(context.Background(), ds, []string{"en", "fr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string][]string)
+	for _, e := range es {
+		if !e.IsOverridable {
+			t.Errorf("%s isn't overridable", e.Name)
+		}
+		if len(e.Entries["en"]) != len(e.Entries["fr"]) {
+			t.Errorf("%s: %d English entries and %d French ones", e.Name, len(e.Entries["en"]), len(e.Entries["fr"]))
+		}
+		for _, en := range e.Entries["en"] {
+			values[e.Name] = append(values[e.Name], en.Value)
+		}
+	}
+	want := map[string][]string{
+		"alcohol":    {"Alcoholic", "Non alcoholic"},
+		"drink-type": {"Cocktail", "Soft Drink"},
+		"glass":      {"Highball glass"},
+		"ingredient": {"Lemon", "Light rum", "Lime", "Sugar"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %q, expected %q", values, want)
+	}
+
+	// The entries of each language have their own synonyms
+	byName := make(map[string]*agent.Entity, len(es))
+	for _, e := range es {
+		byName[e.Name] = e
+	}
+	if got := byName["ingredient"].Entries["fr"][1].Synonyms; !reflect.DeepEqual(got, []string{"Light rum", "rhum blanc"}) {
+		t.Errorf("got French synonyms %q for Light rum", got)
+	}
+	if got := byName["drink-type"].Entries["en"][1].Synonyms; !reflect.DeepEqual(got, []string{"Soft Drink", "soft drinks", "soda", "sodas"}) {
+		t.Errorf("got English synonyms %q for Soft Drink", got)
+	}
+
+	if _, err := Generate(context.Background(), failingLister{ds, cocktail.Glasses}, []string{"en"}); err == nil || !strings.Contains(err.Error(), "couldn't list glass") {
+		t.Errorf("got %v, expected a list error", err)
+	}
+	if _, err := Generate(context.Background(), cocktail.Drinks{}, []string{"en"}); err == nil || !strings.Contains(err.Error(), "no value for alcohol") {
+		t.Errorf("got %v, expected an empty list error", err)
+	}
+}
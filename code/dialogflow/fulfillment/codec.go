@@ -0,0 +1,37 @@
+package fulfillment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Names of the available codecs
+const (
+	LeboncoinCodec = "leboncoin"
+	ProtobufCodec  = "protobuf"
+)
+
+var codecs = map[string]Codec{
+	LeboncoinCodec: Leboncoin{},
+	ProtobufCodec:  Protobuf{},
+}
+
+// Codecs returns the sorted names of the available codecs
+func Codecs() []string {
+	ns := make([]string, 0, len(codecs))
+	for n := range codecs {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+// CodecFor returns the codec with the given name
+func CodecFor(name string) (Codec, error) {
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q, expected one of %s", name, strings.Join(Codecs(), ", "))
+	}
+	return c, nil
+}
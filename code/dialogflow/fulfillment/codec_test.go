@@ -0,0 +1,41 @@
+package fulfillment_test
+
+import (
+	"testing"
+
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/replay"
+)
+
+const testdata = "../testdata/replay"
+
+// TestCodecs replays the recorded conversations through every Dialogflow ES
+// codec, which must all give the responses of the shared golden files
+func TestCodecs(t *testing.T) {
+	fs, err := replay.LoadFixtures(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{fulfillment.LeboncoinCodec, fulfillment.ProtobufCodec} {
+		codec, err := fulfillment.CodecFor(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := replay.NewRunner(testdata, false, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range fs {
+			f := f
+			t.Run(name+"/"+f.Name, func(t *testing.T) {
+				res := r.RunFixture(f)
+				if res.Err != nil {
+					t.Fatal(res.Err)
+				}
+				if res.Diff != "" {
+					t.Errorf("%s codec doesn't answer like %s:\n%s", name, f.Golden(), res.Diff)
+				}
+			})
+		}
+	}
+}
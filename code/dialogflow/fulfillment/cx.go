@@ -0,0 +1,221 @@
+package fulfillment
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"sort"
+)
+
+// CX decodes the requests and encodes the responses of Dialogflow CX. It isn't
+// part of Codecs since CX requests are told apart with IsCX.
+//
+// CX has neither actions nor contexts: the fulfillment tag is used as the
+// action, and the contexts are stored as session parameters holding an object
+// and named after the context. Since CX has no lifespans either, a context is
+// kept until the webhook sends it with a zero lifespan, which removes it.
+type CX struct{}
+
+type cxRequest struct {
+	DetectIntentResponseID string                 `json:"detectIntentResponseId"`
+	IntentInfo             *cxIntentInfo          `json:"intentInfo"`
+	PageInfo               *cxPageInfo            `json:"pageInfo"`
+	SessionInfo            cxSessionInfo          `json:"sessionInfo"`
+	FulfillmentInfo        cxFulfillmentInfo      `json:"fulfillmentInfo"`
+	Text                   string                 `json:"text"`
+	Transcript             string                 `json:"transcript"`
+	LanguageCode           string                 `json:"languageCode"`
+	Payload                map[string]interface{} `json:"payload"`
+}
+
+type cxIntentInfo struct {
+	LastMatchedIntent string                       `json:"lastMatchedIntent"`
+	DisplayName       string                       `json:"displayName"`
+	Parameters        map[string]cxIntentParameter `json:"parameters"`
+	Confidence        float64                      `json:"confidence"`
+}
+
+type cxIntentParameter struct {
+	OriginalValue string      `json:"originalValue"`
+	ResolvedValue interface{} `json:"resolvedValue"`
+}
+
+type cxPageInfo struct {
+	CurrentPage string `json:"currentPage"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+type cxSessionInfo struct {
+	Session    string                 `json:"session,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type cxFulfillmentInfo struct {
+	Tag string `json:"tag"`
+}
+
+type cxResponse struct {
+	FulfillmentResponse *cxFulfillmentResponse `json:"fulfillmentResponse,omitempty"`
+	SessionInfo         *cxSessionInfo         `json:"sessionInfo,omitempty"`
+	TargetPage          string                 `json:"targetPage,omitempty"`
+	TargetFlow          string                 `json:"targetFlow,omitempty"`
+}
+
+type cxFulfillmentResponse struct {
+	Messages []cxMessage `json:"messages"`
+}
+
+type cxMessage struct {
+	Text            *cxText                `json:"text,omitempty"`
+	OutputAudioText *cxOutputAudioText     `json:"outputAudioText,omitempty"`
+	Payload         map[string]interface{} `json:"payload,omitempty"`
+}
+
+type cxText struct {
+	Text []string `json:"text"`
+}
+
+type cxOutputAudioText struct {
+	Text string `json:"text,omitempty"`
+	SSML string `json:"ssml,omitempty"`
+}
+
+// IsCX tells whether the body is a Dialogflow CX request rather than an ES one
+func IsCX(body []byte) bool {
+	var probe struct {
+		QueryResult     json.RawMessage `json:"queryResult"`
+		FulfillmentInfo json.RawMessage `json:"fulfillmentInfo"`
+		SessionInfo     json.RawMessage `json:"sessionInfo"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return false
+	}
+	return probe.QueryResult == nil && (probe.FulfillmentInfo != nil || probe.SessionInfo != nil)
+}
+
+// Decode implements Codec. The session parameters holding an object become
+// contexts and the other ones are added to the parameters, which are then
+// overridden by the intent parameters along with their original value.
+func (CX) Decode(r io.Reader) (*Request, error) {
+	var cr cxRequest
+	if err := json.NewDecoder(r).Decode(&cr); err != nil {
+		return nil, err
+	}
+	req := &Request{
+		Session:    cr.SessionInfo.Session,
+		ResponseID: cr.DetectIntentResponseID,
+		QueryText:  cr.Text,
+		Action:     cr.FulfillmentInfo.Tag,
+		Language:   cr.LanguageCode,
+		Parameters: make(map[string]interface{}),
+		Payload:    cr.Payload,
+	}
+	if req.QueryText == "" {
+		req.QueryText = cr.Transcript
+	}
+	if cr.PageInfo != nil {
+		req.Page = cr.PageInfo.CurrentPage
+	}
+
+	names := make([]string, 0, len(cr.SessionInfo.Parameters))
+	for n := range cr.SessionInfo.Parameters {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		v := cr.SessionInfo.Parameters[n]
+		if m, ok := v.(map[string]interface{}); ok {
+			req.Contexts = append(req.Contexts, Context{Name: req.Session + "/contexts/" + n, Parameters: m})
+			continue
+		}
+		req.Parameters[n] = v
+	}
+
+	if ii := cr.IntentInfo; ii != nil {
+		req.Intent = ii.DisplayName
+		req.Confidence = ii.Confidence
+		for n, p := range ii.Parameters {
+			req.Parameters[n] = p.ResolvedValue
+			req.Parameters[n+".original"] = p.OriginalValue
+		}
+	}
+	return req, nil
+}
+
+// Encode implements Codec. The output contexts are sent as session parameter
+// updates, along with the parameters of the response.
+func (CX) Encode(w io.Writer, resp *Response) error {
+	cr := cxResponse{TargetPage: resp.TargetPage, TargetFlow: resp.TargetFlow}
+
+	fr := &cxFulfillmentResponse{Messages: []cxMessage{}}
+	for _, m := range resp.Messages {
+		if cm, ok := cxMessageOf(m); ok {
+			fr.Messages = append(fr.Messages, cm)
+		}
+	}
+	if len(fr.Messages) == 0 && resp.Text != "" {
+		fr.Messages = append(fr.Messages, cxMessage{Text: &cxText{Text: []string{resp.Text}}})
+	}
+	if len(fr.Messages) > 0 {
+		cr.FulfillmentResponse = fr
+	}
+
+	ps := make(map[string]interface{}, len(resp.Parameters)+len(resp.OutputContexts))
+	for k, v := range resp.Parameters {
+		ps[k] = v
+	}
+	for _, c := range resp.OutputContexts {
+		var v interface{}
+		if c.Lifespan > 0 {
+			v = c.Parameters
+		}
+		ps[path.Base(c.Name)] = v
+	}
+	if len(ps) > 0 {
+		cr.SessionInfo = &cxSessionInfo{Parameters: ps}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(cr)
+}
+
+// cxMessageOf converts a message to its CX counterpart. Simple responses are
+// spoken with their SSML, while cards and suggestions use the rich content
+// payload understood by Dialogflow Messenger.
+func cxMessageOf(m Message) (cxMessage, bool) {
+	switch {
+	case m.Text != nil:
+		return cxMessage{Text: &cxText{Text: m.Text}}, true
+	case len(m.SimpleResponses) > 0:
+		sr := m.SimpleResponses[0]
+		if sr.SSML != "" {
+			return cxMessage{OutputAudioText: &cxOutputAudioText{SSML: sr.SSML}}, true
+		}
+		return cxMessage{OutputAudioText: &cxOutputAudioText{Text: sr.TextToSpeech}}, true
+	case m.BasicCard != nil:
+		info := map[string]interface{}{"type": "info", "title": m.BasicCard.Title}
+		if m.BasicCard.Subtitle != "" {
+			info["subtitle"] = m.BasicCard.Subtitle
+		} else if m.BasicCard.FormattedText != "" {
+			info["subtitle"] = m.BasicCard.FormattedText
+		}
+		if i := m.BasicCard.Image; i != nil {
+			info["image"] = map[string]interface{}{"src": map[string]interface{}{"rawUrl": i.URI}}
+		}
+		return richContent(info), true
+	case m.Suggestions != nil:
+		opts := make([]interface{}, 0, len(m.Suggestions))
+		for _, s := range m.Suggestions {
+			opts = append(opts, map[string]interface{}{"text": s})
+		}
+		return richContent(map[string]interface{}{"type": "chips", "options": opts}), true
+	}
+	return cxMessage{}, false
+}
+
+func richContent(item map[string]interface{}) cxMessage {
+	return cxMessage{Payload: map[string]interface{}{
+		"richContent": []interface{}{[]interface{}{item}},
+	}}
+}
@@ -0,0 +1,148 @@
+// Package fulfillment defines the webhook request and response independently
+// from the library used to decode and encode them, so that the actions are
+// written once whatever the backend.
+package fulfillment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+)
+
+// ErrContextNotFound is returned when the request doesn't hold the context
+var ErrContextNotFound = errors.New("context not found")
+
+// Request is a webhook request
+type Request struct {
+	Session    string
+	ResponseID string
+	QueryText  string
+	Action     string
+	Intent     string
+	Language   string
+	Confidence float64
+	Parameters map[string]interface{}
+	Contexts   []Context
+	// Payload is the payload of the original detect intent request, which holds
+	// the Actions on Google user for example
+	Payload map[string]interface{}
+	// Page is the current page of the session, only set by Dialogflow CX
+	Page string
+}
+
+// Context is an input or output context. Name is the full name of the context,
+// including the session.
+type Context struct {
+	Name       string
+	Lifespan   int
+	Parameters map[string]interface{}
+}
+
+// GetParams decodes the request parameters into v, using its JSON tags
+func (r *Request) GetParams(v interface{}) error {
+	return convert(r.Parameters, v)
+}
+
+// GetContext decodes the parameters of the context with the given short name
+// into v
+func (r *Request) GetContext(name string, v interface{}) error {
+	for _, c := range r.Contexts {
+		if path.Base(c.Name) == name {
+			return convert(c.Parameters, v)
+		}
+	}
+	return ErrContextNotFound
+}
+
+// NewContext returns a context of the request session holding the given
+// parameters, which are encoded using their JSON tags
+func (r *Request) NewContext(name string, lifespan int, params interface{}) (Context, error) {
+	c := Context{Name: r.Session + "/contexts/" + name, Lifespan: lifespan}
+	return c, convert(params, &c.Parameters)
+}
+
+// convert goes through JSON to turn a struct into a map or the other way around
+func convert(from, to interface{}) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, to)
+}
+
+// Response is a webhook response
+type Response struct {
+	Text           string
+	Messages       []Message
+	OutputContexts []Context
+
+	// Parameters updates the session parameters, a nil value removing the
+	// parameter. Only used by Dialogflow CX.
+	Parameters map[string]interface{}
+	// TargetPage and TargetFlow are the full names of the page or flow the
+	// session transitions to. Only used by Dialogflow CX.
+	TargetPage string
+	TargetFlow string
+}
+
+// TextResponse returns a response made of a single text message
+func TextResponse(s string) *Response {
+	return &Response{Text: s, Messages: []Message{{Text: []string{s}}}}
+}
+
+// Platform is the platform a message is meant for, empty meaning all of them
+type Platform string
+
+// ActionsOnGoogle is the platform of the Google Assistant
+const ActionsOnGoogle Platform = "ACTIONS_ON_GOOGLE"
+
+// Message is a rich message, only one of its fields being set
+type Message struct {
+	Platform        Platform
+	Text            []string
+	SimpleResponses []SimpleResponse
+	BasicCard       *BasicCard
+	Suggestions     []string
+}
+
+// SimpleResponse is a spoken response along with its displayed text
+type SimpleResponse struct {
+	TextToSpeech string
+	SSML         string
+	DisplayText  string
+}
+
+// BasicCard is a card with an optional image
+type BasicCard struct {
+	Title         string
+	Subtitle      string
+	FormattedText string
+	Image         *Image
+}
+
+// Image is an image and its description
+type Image struct {
+	URI               string
+	AccessibilityText string
+}
+
+// Handler answers webhook requests
+type Handler interface {
+	Fulfill(ctx context.Context, r *Request) (*Response, error)
+}
+
+// HandlerFunc turns a function into a Handler
+type HandlerFunc func(ctx context.Context, r *Request) (*Response, error)
+
+// Fulfill calls f
+func (f HandlerFunc) Fulfill(ctx context.Context, r *Request) (*Response, error) {
+	return f(ctx, r)
+}
+
+// Codec decodes the requests sent by Dialogflow and encodes the responses
+type Codec interface {
+	Decode(r io.Reader) (*Request, error)
+	Encode(w io.Writer, resp *Response) error
+}
@@ -0,0 +1,92 @@
+package fulfillment
+
+import (
+	"encoding/json"
+	"io"
+
+	df "github.com/leboncoin/dialogflow-go-webhook"
+)
+
+// Leboncoin decodes and encodes the webhook messages with the types of the
+// dialogflow-go-webhook library
+type Leboncoin struct{}
+
+// Decode implements Codec
+func (Leboncoin) Decode(r io.Reader) (*Request, error) {
+	var dfr df.Request
+	if err := json.NewDecoder(r).Decode(&dfr); err != nil {
+		return nil, err
+	}
+	qr := dfr.QueryResult
+	req := &Request{
+		Session:    dfr.Session,
+		ResponseID: dfr.ResponseID,
+		QueryText:  qr.QueryText,
+		Action:     qr.Action,
+		Intent:     qr.Intent.DisplayName,
+		Language:   qr.LanguageCode,
+		Confidence: qr.IntentDetectionConfidence,
+	}
+	if err := rawToMap(qr.Parameters, &req.Parameters); err != nil {
+		return nil, err
+	}
+	if err := rawToMap(dfr.OriginalDetectIntentRequest.Payload, &req.Payload); err != nil {
+		return nil, err
+	}
+	for _, c := range qr.OutputContexts {
+		ctx := Context{Name: c.Name, Lifespan: c.LifespanCount}
+		if err := rawToMap(c.Parameters, &ctx.Parameters); err != nil {
+			return nil, err
+		}
+		req.Contexts = append(req.Contexts, ctx)
+	}
+	return req, nil
+}
+
+func rawToMap(raw json.RawMessage, m *map[string]interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, m)
+}
+
+// Encode implements Codec
+func (Leboncoin) Encode(w io.Writer, resp *Response) error {
+	dff := df.Fulfillment{FulfillmentText: resp.Text}
+	for _, m := range resp.Messages {
+		var rm df.RichMessage
+		switch {
+		case m.Text != nil:
+			rm = df.Text{Text: m.Text}
+		case m.SimpleResponses != nil:
+			srs := make([]df.SimpleResponse, 0, len(m.SimpleResponses))
+			for _, sr := range m.SimpleResponses {
+				srs = append(srs, df.SimpleResponse{TextToSpeech: sr.TextToSpeech, SSML: sr.SSML, DisplayText: sr.DisplayText})
+			}
+			rm = df.SimpleResponses{SimpleResponses: srs}
+		case m.BasicCard != nil:
+			card := df.BasicCard{Title: m.BasicCard.Title, Subtitle: m.BasicCard.Subtitle, FormattedText: m.BasicCard.FormattedText}
+			if i := m.BasicCard.Image; i != nil {
+				card.Image = &df.Image{ImageURI: i.URI, AccessibilityText: i.AccessibilityText}
+			}
+			rm = card
+		case m.Suggestions != nil:
+			ss := make([]df.Suggestion, 0, len(m.Suggestions))
+			for _, s := range m.Suggestions {
+				ss = append(ss, df.Suggestion{Title: s})
+			}
+			rm = df.Suggestions{Suggestions: ss}
+		default:
+			continue
+		}
+		dff.FulfillmentMessages = append(dff.FulfillmentMessages, df.Message{Platform: df.Platform(m.Platform), RichMessage: rm})
+	}
+	for _, c := range resp.OutputContexts {
+		b, err := json.Marshal(c.Parameters)
+		if err != nil {
+			return err
+		}
+		dff.OutputContexts = append(dff.OutputContexts, &df.Context{Name: c.Name, LifespanCount: c.Lifespan, Parameters: b})
+	}
+	return json.NewEncoder(w).Encode(dff)
+}
@@ -0,0 +1,101 @@
+package fulfillment
+
+import (
+	"io"
+
+	"github.com/golang/protobuf/jsonpb"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+	"github.com/Depado/articles/code/dialogflow/params"
+)
+
+// Protobuf decodes and encodes the webhook messages with the protobuf types of
+// the Dialogflow API, using jsonpb
+type Protobuf struct{}
+
+// Decode implements Codec. Unknown fields are allowed since Dialogflow adds
+// fields over time.
+func (Protobuf) Decode(r io.Reader) (*Request, error) {
+	var wr dialogflow.WebhookRequest
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r, &wr); err != nil {
+		return nil, err
+	}
+	qr := wr.GetQueryResult()
+	req := &Request{
+		Session:    wr.GetSession(),
+		ResponseID: wr.GetResponseId(),
+		QueryText:  qr.GetQueryText(),
+		Action:     qr.GetAction(),
+		Intent:     qr.GetIntent().GetDisplayName(),
+		Language:   qr.GetLanguageCode(),
+		Confidence: float64(qr.GetIntentDetectionConfidence()),
+		Parameters: structToMap(qr.GetParameters()),
+		Payload:    structToMap(wr.GetOriginalDetectIntentRequest().GetPayload()),
+	}
+	for _, c := range qr.GetOutputContexts() {
+		req.Contexts = append(req.Contexts, Context{
+			Name:       c.GetName(),
+			Lifespan:   int(c.GetLifespanCount()),
+			Parameters: structToMap(c.GetParameters()),
+		})
+	}
+	return req, nil
+}
+
+// Encode implements Codec
+func (Protobuf) Encode(w io.Writer, resp *Response) error {
+	wr := &dialogflow.WebhookResponse{FulfillmentText: resp.Text}
+	for _, m := range resp.Messages {
+		im := &dialogflow.Intent_Message{Platform: platform(m.Platform)}
+		switch {
+		case m.Text != nil:
+			im.Message = &dialogflow.Intent_Message_Text_{Text: &dialogflow.Intent_Message_Text{Text: m.Text}}
+		case m.SimpleResponses != nil:
+			srs := make([]*dialogflow.Intent_Message_SimpleResponse, 0, len(m.SimpleResponses))
+			for _, sr := range m.SimpleResponses {
+				srs = append(srs, &dialogflow.Intent_Message_SimpleResponse{TextToSpeech: sr.TextToSpeech, Ssml: sr.SSML, DisplayText: sr.DisplayText})
+			}
+			im.Message = &dialogflow.Intent_Message_SimpleResponses_{SimpleResponses: &dialogflow.Intent_Message_SimpleResponses{SimpleResponses: srs}}
+		case m.BasicCard != nil:
+			card := &dialogflow.Intent_Message_BasicCard{Title: m.BasicCard.Title, Subtitle: m.BasicCard.Subtitle, FormattedText: m.BasicCard.FormattedText}
+			if i := m.BasicCard.Image; i != nil {
+				card.Image = &dialogflow.Intent_Message_Image{ImageUri: i.URI, AccessibilityText: i.AccessibilityText}
+			}
+			im.Message = &dialogflow.Intent_Message_BasicCard_{BasicCard: card}
+		case m.Suggestions != nil:
+			ss := make([]*dialogflow.Intent_Message_Suggestion, 0, len(m.Suggestions))
+			for _, s := range m.Suggestions {
+				ss = append(ss, &dialogflow.Intent_Message_Suggestion{Title: s})
+			}
+			im.Message = &dialogflow.Intent_Message_Suggestions_{Suggestions: &dialogflow.Intent_Message_Suggestions{Suggestions: ss}}
+		default:
+			continue
+		}
+		wr.FulfillmentMessages = append(wr.FulfillmentMessages, im)
+	}
+	for _, c := range resp.OutputContexts {
+		s, err := params.Encode(c.Parameters)
+		if err != nil {
+			return err
+		}
+		wr.OutputContexts = append(wr.OutputContexts, &dialogflow.Context{Name: c.Name, LifespanCount: int32(c.Lifespan), Parameters: s})
+	}
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, wr)
+}
+
+// platform returns the protobuf enum value of the platform
+func platform(p Platform) dialogflow.Intent_Message_Platform {
+	return dialogflow.Intent_Message_Platform(dialogflow.Intent_Message_Platform_value[string(p)])
+}
+
+// structToMap converts a protobuf Struct to a map holding the same values as
+// if it had been decoded by encoding/json, nil for a nil Struct
+func structToMap(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return params.Interface(&structpb.Value{Kind: &structpb.Value_StructValue{StructValue: s}}).(map[string]interface{})
+}
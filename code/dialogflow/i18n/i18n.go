@@ -0,0 +1,230 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultFallback is the locale used when the requested one isn't shipped or
+// doesn't define a key
+const DefaultFallback = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Vars holds the values of the placeholders of a message, a placeholder is
+// written {name} in the catalog files
+type Vars map[string]interface{}
+
+// message is a single catalog entry. In the catalog files it is either a
+// plain string or an object holding the plural forms.
+type message struct {
+	Zero  string `json:"zero"`
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+// UnmarshalJSON allows a message to be declared as a plain string
+func (m *message) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		m.Other = s
+		return nil
+	}
+	type plain message
+	var p plain
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	if p.Other == "" {
+		return fmt.Errorf("plural message must define the 'other' form")
+	}
+	*m = message(p)
+	return nil
+}
+
+// Catalog holds the messages of every locale
+type Catalog struct {
+	Fallback string
+	locales  map[string]map[string]message
+}
+
+// Load reads every <locale>.json file found in the root directory of fsys. An
+// error is returned if a locale misses a key defined by the fallback one.
+func Load(fsys fs.FS, fallback string) (*Catalog, error) {
+	var err error
+	var paths []string
+
+	if paths, err = fs.Glob(fsys, "*.json"); err != nil {
+		return nil, err
+	}
+	c := &Catalog{Fallback: fallback, locales: make(map[string]map[string]message)}
+	for _, p := range paths {
+		var b []byte
+		var msgs map[string]message
+		if b, err = fs.ReadFile(fsys, p); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &msgs); err != nil {
+			return nil, fmt.Errorf("%s: %v", p, err)
+		}
+		c.locales[strings.ToLower(strings.TrimSuffix(path.Base(p), ".json"))] = msgs
+	}
+	if _, ok := c.locales[fallback]; !ok {
+		return nil, fmt.Errorf("fallback locale %q not found", fallback)
+	}
+	if missing := c.Missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("missing keys: %s", strings.Join(missing, ", "))
+	}
+	return c, nil
+}
+
+var (
+	def     *Catalog
+	defErr  error
+	defOnce sync.Once
+)
+
+// Default returns the catalog built from the locales shipped with the binary
+func Default() (*Catalog, error) {
+	defOnce.Do(func() {
+		var sub fs.FS
+		if sub, defErr = fs.Sub(locales, "locales"); defErr != nil {
+			return
+		}
+		def, defErr = Load(sub, DefaultFallback)
+	})
+	return def, defErr
+}
+
+// Locales returns the sorted list of available locales
+func (c *Catalog) Locales() []string {
+	ls := make([]string, 0, len(c.locales))
+	for l := range c.locales {
+		ls = append(ls, l)
+	}
+	sort.Strings(ls)
+	return ls
+}
+
+// Has returns true if the catalog ships the given locale
+func (c *Catalog) Has(locale string) bool {
+	_, ok := c.locales[locale]
+	return ok
+}
+
+// Missing returns the keys and plural forms defined in the fallback locale but
+// not in another one, formatted as locale:key or locale:key.form
+func (c *Catalog) Missing() []string {
+	var missing []string
+	for _, l := range c.Locales() {
+		for k, fm := range c.locales[c.Fallback] {
+			m, ok := c.locales[l][k]
+			if !ok {
+				missing = append(missing, l+":"+k)
+				continue
+			}
+			if fm.Zero != "" && m.Zero == "" {
+				missing = append(missing, l+":"+k+".zero")
+			}
+			if fm.One != "" && m.One == "" {
+				missing = append(missing, l+":"+k+".one")
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// For returns a Localizer for the given Dialogflow language code. Regional
+// variants such as "fr-ca" fall back on their base language, and unknown
+// languages on the fallback locale.
+func (c *Catalog) For(languageCode string) *Localizer {
+	lc := strings.ToLower(strings.Replace(languageCode, "_", "-", -1))
+	if _, ok := c.locales[lc]; ok {
+		return &Localizer{c: c, lang: lc}
+	}
+	if i := strings.Index(lc, "-"); i > 0 {
+		if _, ok := c.locales[lc[:i]]; ok {
+			return &Localizer{c: c, lang: lc[:i]}
+		}
+	}
+	return &Localizer{c: c, lang: c.Fallback}
+}
+
+// Localizer renders the messages of a single locale
+type Localizer struct {
+	c    *Catalog
+	lang string
+}
+
+// Lang returns the locale actually used by the localizer
+func (l *Localizer) Lang() string {
+	return l.lang
+}
+
+func (l *Localizer) lookup(key string) (message, bool) {
+	if m, ok := l.c.locales[l.lang][key]; ok {
+		return m, true
+	}
+	m, ok := l.c.locales[l.c.Fallback][key]
+	return m, ok
+}
+
+// T renders the message associated to key. The key itself is returned if no
+// locale defines it.
+func (l *Localizer) T(key string, vars Vars) string {
+	m, ok := l.lookup(key)
+	if !ok {
+		return key
+	}
+	return render(m.Other, vars)
+}
+
+// Plural renders the plural form of the message matching n, which is also
+// available as the {count} placeholder
+func (l *Localizer) Plural(key string, n int, vars Vars) string {
+	m, ok := l.lookup(key)
+	if !ok {
+		return key
+	}
+	v := Vars{"count": n}
+	for k, val := range vars {
+		v[k] = val
+	}
+	s := m.Other
+	switch {
+	case n == 0 && m.Zero != "":
+		s = m.Zero
+	case pluralOne(l.lang, n) && m.One != "":
+		s = m.One
+	}
+	return render(s, v)
+}
+
+// pluralOne returns true if n uses the singular form in the given language
+func pluralOne(lang string, n int) bool {
+	switch lang {
+	case "fr":
+		return n == 0 || n == 1
+	default:
+		return n == 1
+	}
+}
+
+func render(s string, vars Vars) string {
+	if len(vars) == 0 {
+		return s
+	}
+	pairs := make([]string, 0, len(vars)*2)
+	for k, v := range vars {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
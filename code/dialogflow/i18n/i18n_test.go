@@ -0,0 +1,128 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// placeholder matches the {name} placeholders of a message
+var placeholder = regexp.MustCompile(`\{[\w.-]+\}`)
+
+// forms returns the non-empty plural forms of a message
+func forms(m message) map[string]string {
+	fs := make(map[string]string)
+	for f, s := range map[string]string{"zero": m.Zero, "one": m.One, "other": m.Other} {
+		if s != "" {
+			fs[f] = s
+		}
+	}
+	return fs
+}
+
+// placeholders returns the sorted and deduplicated placeholders of s
+func placeholders(s string) string {
+	seen := make(map[string]bool)
+	for _, p := range placeholder.FindAllString(s, -1) {
+		seen[p] = true
+	}
+	ps := make([]string, 0, len(seen))
+	for p := range seen {
+		ps = append(ps, p)
+	}
+	sort.Strings(ps)
+	return strings.Join(ps, " ")
+}
+
+func TestDefaultLocales(t *testing.T) {
+	c, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Locales()) < 2 {
+		t.Fatalf("expected several locales, got %v", c.Locales())
+	}
+	if m := c.Missing(); len(m) > 0 {
+		t.Errorf("missing keys: %v", m)
+	}
+	fb := c.locales[c.Fallback]
+	for _, l := range c.Locales() {
+		if l == c.Fallback {
+			continue
+		}
+		msgs := c.locales[l]
+		for k := range fb {
+			if _, ok := msgs[k]; !ok {
+				t.Errorf("%s: key %q of %s is missing", l, k, c.Fallback)
+			}
+		}
+		for k, m := range msgs {
+			fm, ok := fb[k]
+			if !ok {
+				t.Errorf("%s: key %q isn't defined by %s", l, k, c.Fallback)
+				continue
+			}
+			ffs, lfs := forms(fm), forms(m)
+			for f, s := range ffs {
+				ls, ok := lfs[f]
+				if !ok {
+					t.Errorf("%s: %s lacks the %q form", l, k, f)
+					continue
+				}
+				if fp, lp := placeholders(s), placeholders(ls); fp != lp {
+					t.Errorf("%s: %s.%s uses placeholders [%s], %s uses [%s]", l, k, f, lp, c.Fallback, fp)
+				}
+			}
+			for f := range lfs {
+				if _, ok := ffs[f]; !ok {
+					t.Errorf("%s: %s defines the %q form which %s doesn't", l, k, f, c.Fallback)
+				}
+			}
+		}
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	fsys := fstest.MapFS{
+		"en.json": {Data: []byte(`{"a": "A", "b": {"zero": "no b", "one": "one b", "other": "{count} b"}}`)},
+		"fr.json": {Data: []byte(`{"b": {"other": "{count} b"}}`)},
+	}
+	_, err := Load(fsys, "en")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, k := range []string{"fr:a", "fr:b.zero", "fr:b.one"} {
+		if !strings.Contains(err.Error(), k) {
+			t.Errorf("expected %s to be reported, got %v", k, err)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	c, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		lang string
+		n    int
+		want string
+	}{
+		{"en", 0, "You don't have any favorite cocktail yet."},
+		{"en", 1, "Your favorite cocktail is Mojito."},
+		{"en", 2, "Your 2 favorite cocktails are Mojito."},
+		{"fr", 0, "Vous n'avez encore aucun cocktail favori."},
+		{"fr", 1, "Votre cocktail favori est Mojito."},
+		{"fr", 3, "Vos 3 cocktails favoris sont Mojito."},
+		{"fr-CA", 1, "Votre cocktail favori est Mojito."},
+		{"de", 1, "Your favorite cocktail is Mojito."},
+	}
+	for _, tt := range tests {
+		got := c.For(tt.lang).Plural("favorites.list", tt.n, Vars{"list": "Mojito"})
+		if got != tt.want {
+			t.Errorf("%s %d: got %q, want %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+// Package params converts the protobuf Structs holding the Dialogflow
+// parameters from and to Go values.
+//
+// Struct fields are matched using their df tag, falling back on their json tag
+// and then on their name, and fields tagged "-" are ignored. The "original"
+// option of the df tag reads or writes the text the user actually said, which
+// Dialogflow sends under the key suffixed with ".original":
+//
+//	type searchParams struct {
+//		Alcohol         string `df:"alcohol"`
+//		AlcoholOriginal string `df:"alcohol,original"`
+//	}
+//
+// This package mirrors dialogflowpb/params so that this tree, which isn't part
+// of the dialogflowpb module, doesn't import across module boundaries. Keep
+// both copies in sync.
+package params
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// OriginalSuffix is the suffix of the keys holding the original text of a
+// parameter
+const OriginalSuffix = ".original"
+
+var (
+	valueType  = reflect.TypeOf(&structpb.Value{})
+	structType = reflect.TypeOf(&structpb.Struct{})
+)
+
+// TypeError is returned when a value can't be decoded into a Go value
+type TypeError struct {
+	// Path is the path of the value, for example "drinks[1].glass"
+	Path string
+	// Value describes the protobuf value, for example "number 2.5"
+	Value string
+	// Type is the type of the Go value
+	Type reflect.Type
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("params: %s: cannot decode %s into %s", e.Path, e.Value, e.Type)
+}
+
+// Decode stores the fields of the Struct in the value pointed to by v, which
+// can be a struct, a map with string keys or an empty interface. Unknown keys
+// are ignored and missing ones leave the fields untouched.
+//
+// Dialogflow sends an empty string for the parameters that weren't filled, so
+// empty strings decode to the zero value whatever the type of the field.
+func Decode(s *structpb.Struct, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("params: cannot decode into %T, a non-nil pointer is needed", v)
+	}
+	if s == nil {
+		return nil
+	}
+	return decodeStruct(s, rv.Elem(), "")
+}
+
+// DecodeValue stores the protobuf value in the value pointed to by v
+func DecodeValue(pv *structpb.Value, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("params: cannot decode into %T, a non-nil pointer is needed", v)
+	}
+	return decode(pv, rv.Elem(), "")
+}
+
+func decodeStruct(s *structpb.Struct, rv reflect.Value, p string) error {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decodeStruct(s, rv.Elem(), p)
+	case reflect.Interface:
+		if rv.NumMethod() == 0 {
+			rv.Set(reflect.ValueOf(Interface(&structpb.Value{Kind: &structpb.Value_StructValue{StructValue: s}})))
+			return nil
+		}
+	case reflect.Struct:
+		for _, f := range fields(rv.Type()) {
+			pv, ok := s.GetFields()[f.key]
+			if !ok {
+				continue
+			}
+			if err := decode(pv, rv.FieldByIndex(f.index), join(p, f.key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), len(s.GetFields())))
+		}
+		et := rv.Type().Elem()
+		for k, pv := range s.GetFields() {
+			ev := reflect.New(et).Elem()
+			if err := decode(pv, ev, join(p, k)); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()), ev)
+		}
+		return nil
+	}
+	return &TypeError{Path: path(p), Value: "struct", Type: rv.Type()}
+}
+
+func decode(pv *structpb.Value, rv reflect.Value, p string) error {
+	if _, ok := pv.GetKind().(*structpb.Value_NullValue); ok || pv.GetKind() == nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if s, ok := pv.GetKind().(*structpb.Value_StringValue); ok && s.StringValue == "" {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	switch rv.Type() {
+	case valueType:
+		rv.Set(reflect.ValueOf(pv))
+		return nil
+	case structType:
+		if s, ok := pv.GetKind().(*structpb.Value_StructValue); ok {
+			rv.Set(reflect.ValueOf(s.StructValue))
+			return nil
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decode(pv, rv.Elem(), p)
+	case reflect.Interface:
+		if rv.NumMethod() == 0 {
+			rv.Set(reflect.ValueOf(Interface(pv)))
+			return nil
+		}
+		return mismatch(pv, rv, p)
+	}
+
+	switch k := pv.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		if rv.Kind() == reflect.String {
+			rv.SetString(k.StringValue)
+			return nil
+		}
+	case *structpb.Value_BoolValue:
+		if rv.Kind() == reflect.Bool {
+			rv.SetBool(k.BoolValue)
+			return nil
+		}
+	case *structpb.Value_NumberValue:
+		return decodeNumber(k.NumberValue, rv, p)
+	case *structpb.Value_StructValue:
+		return decodeStruct(k.StructValue, rv, p)
+	case *structpb.Value_ListValue:
+		return decodeList(k.ListValue, rv, p)
+	}
+
+	// A list parameter may be sent as a single value
+	if rv.Kind() == reflect.Slice {
+		rv.Set(reflect.MakeSlice(rv.Type(), 1, 1))
+		return decode(pv, rv.Index(0), fmt.Sprintf("%s[0]", p))
+	}
+	return mismatch(pv, rv, p)
+}
+
+func decodeNumber(f float64, rv reflect.Value, p string) error {
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if !rv.OverflowFloat(f) {
+			rv.SetFloat(f)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// The bounds are checked on the float since the conversion saturates
+		// or wraps around, 2^(bits-1) itself being out of range
+		max := math.Ldexp(1, rv.Type().Bits()-1)
+		if f == math.Trunc(f) && f >= -max && f < max {
+			rv.SetInt(int64(f))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f >= 0 && f == math.Trunc(f) && f < math.Ldexp(1, rv.Type().Bits()) {
+			rv.SetUint(uint64(f))
+			return nil
+		}
+	case reflect.Slice:
+		rv.Set(reflect.MakeSlice(rv.Type(), 1, 1))
+		return decodeNumber(f, rv.Index(0), fmt.Sprintf("%s[0]", p))
+	}
+	return &TypeError{Path: path(p), Value: fmt.Sprintf("number %v", f), Type: rv.Type()}
+}
+
+func decodeList(l *structpb.ListValue, rv reflect.Value, p string) error {
+	vs := l.GetValues()
+	switch rv.Kind() {
+	case reflect.Slice:
+		rv.Set(reflect.MakeSlice(rv.Type(), len(vs), len(vs)))
+	case reflect.Array:
+		if rv.Len() != len(vs) {
+			return &TypeError{Path: path(p), Value: fmt.Sprintf("list of %d values", len(vs)), Type: rv.Type()}
+		}
+	default:
+		return &TypeError{Path: path(p), Value: "list", Type: rv.Type()}
+	}
+	for i, pv := range vs {
+		if err := decode(pv, rv.Index(i), fmt.Sprintf("%s[%d]", p, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mismatch(pv *structpb.Value, rv reflect.Value, p string) error {
+	var v string
+	switch k := pv.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		v = fmt.Sprintf("string %q", k.StringValue)
+	case *structpb.Value_BoolValue:
+		v = fmt.Sprintf("bool %v", k.BoolValue)
+	case *structpb.Value_NumberValue:
+		v = fmt.Sprintf("number %v", k.NumberValue)
+	case *structpb.Value_StructValue:
+		v = "struct"
+	case *structpb.Value_ListValue:
+		v = "list"
+	}
+	return &TypeError{Path: path(p), Value: v, Type: rv.Type()}
+}
+
+// Interface converts a protobuf value to its plain Go representation, lists
+// being []interface{} and structs map[string]interface{}
+func Interface(pv *structpb.Value) interface{} {
+	switch k := pv.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return k.StringValue
+	case *structpb.Value_BoolValue:
+		return k.BoolValue
+	case *structpb.Value_NumberValue:
+		return k.NumberValue
+	case *structpb.Value_StructValue:
+		m := make(map[string]interface{}, len(k.StructValue.GetFields()))
+		for n, v := range k.StructValue.GetFields() {
+			m[n] = Interface(v)
+		}
+		return m
+	case *structpb.Value_ListValue:
+		l := make([]interface{}, len(k.ListValue.GetValues()))
+		for i, v := range k.ListValue.GetValues() {
+			l[i] = Interface(v)
+		}
+		return l
+	}
+	return nil
+}
+
+// field is a struct field along with its key in the Struct
+type field struct {
+	index     []int
+	key       string
+	omitempty bool
+}
+
+// fields returns the exported fields of the struct type with their key. The
+// fields of embedded structs without tag are promoted as encoding/json does,
+// the shallowest field winning when several have the same key. Embedded
+// pointers to structs aren't supported and are ignored.
+func fields(t reflect.Type) []field {
+	var fs []field
+	seen := make(map[string]int)
+	for _, f := range appendFields(nil, t, nil) {
+		i, ok := seen[f.key]
+		switch {
+		case !ok:
+			seen[f.key] = len(fs)
+			fs = append(fs, f)
+		case len(f.index) < len(fs[i].index):
+			fs[i] = f
+		}
+	}
+	return fs
+}
+
+func appendFields(fs []field, t reflect.Type, index []int) []field {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("df")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		idx := append(append([]int(nil), index...), sf.Index...)
+		if sf.Anonymous && tag == "" {
+			if sf.Type.Kind() == reflect.Struct {
+				fs = appendFields(fs, sf.Type, idx)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		f := field{index: idx, key: sf.Name}
+		opts := strings.Split(tag, ",")
+		if opts[0] != "" {
+			f.key = opts[0]
+		}
+		for _, o := range opts[1:] {
+			switch o {
+			case "omitempty":
+				f.omitempty = true
+			case "original":
+				f.key += OriginalSuffix
+			}
+		}
+		fs = append(fs, f)
+	}
+	return fs
+}
+
+func join(p, key string) string {
+	if p == "" {
+		return key
+	}
+	return p + "." + key
+}
+
+func path(p string) string {
+	if p == "" {
+		return "(root)"
+	}
+	return p
+}
@@ -0,0 +1,116 @@
+package params
+
+import (
+	"fmt"
+	"reflect"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// Encode converts a struct or a map with string keys to a protobuf Struct,
+// nil being returned for a nil value so that the field is omitted. Struct
+// fields use the same tags as Decode and support the "omitempty" option.
+func Encode(v interface{}) (*structpb.Struct, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Map || rv.Kind() == reflect.Ptr) && rv.IsNil() {
+		return nil, nil
+	}
+	pv, err := EncodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := pv.GetKind().(*structpb.Value_StructValue)
+	if !ok {
+		return nil, fmt.Errorf("params: cannot encode %T as a struct", v)
+	}
+	return s.StructValue, nil
+}
+
+// EncodeValue converts a Go value to a protobuf value. Numbers, strings,
+// booleans, structs and slices or maps with string keys of those are
+// supported.
+func EncodeValue(v interface{}) (*structpb.Value, error) {
+	return encode(reflect.ValueOf(v), "")
+}
+
+func encode(rv reflect.Value, p string) (*structpb.Value, error) {
+	if !rv.IsValid() {
+		return &structpb.Value{Kind: &structpb.Value_NullValue{}}, nil
+	}
+	switch t := rv.Interface().(type) {
+	case *structpb.Value:
+		return t, nil
+	case *structpb.Struct:
+		return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: t}}, nil
+	case *structpb.ListValue:
+		return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: t}}, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return &structpb.Value{Kind: &structpb.Value_BoolValue{BoolValue: rv.Bool()}}, nil
+	case reflect.String:
+		return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: rv.String()}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return number(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return number(rv.Float()), nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return encode(reflect.Value{}, p)
+		}
+		return encode(rv.Elem(), p)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return encode(reflect.Value{}, p)
+		}
+		l := &structpb.ListValue{Values: make([]*structpb.Value, rv.Len())}
+		for i := 0; i < rv.Len(); i++ {
+			pv, err := encode(rv.Index(i), fmt.Sprintf("%s[%d]", p, i))
+			if err != nil {
+				return nil, err
+			}
+			l.Values[i] = pv
+		}
+		return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: l}}, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if rv.IsNil() {
+			return encode(reflect.Value{}, p)
+		}
+		s := &structpb.Struct{Fields: make(map[string]*structpb.Value, rv.Len())}
+		for _, k := range rv.MapKeys() {
+			pv, err := encode(rv.MapIndex(k), join(p, k.String()))
+			if err != nil {
+				return nil, err
+			}
+			s.Fields[k.String()] = pv
+		}
+		return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: s}}, nil
+	case reflect.Struct:
+		s := &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+		for _, f := range fields(rv.Type()) {
+			fv := rv.FieldByIndex(f.index)
+			if f.omitempty && fv.IsZero() {
+				continue
+			}
+			pv, err := encode(fv, join(p, f.key))
+			if err != nil {
+				return nil, err
+			}
+			s.Fields[f.key] = pv
+		}
+		return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: s}}, nil
+	}
+	return nil, fmt.Errorf("params: %s: cannot encode %s", path(p), rv.Type())
+}
+
+func number(f float64) *structpb.Value {
+	return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: f}}
+}
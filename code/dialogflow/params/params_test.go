@@ -0,0 +1,231 @@
+package params
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+func num(f float64) *structpb.Value {
+	return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: f}}
+}
+
+func str(s string) *structpb.Value {
+	return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: s}}
+}
+
+func list(vs ...*structpb.Value) *structpb.Value {
+	return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: &structpb.ListValue{Values: vs}}}
+}
+
+func obj(fs map[string]*structpb.Value) *structpb.Value {
+	return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{Fields: fs}}}
+}
+
+func TestDecodeNumber(t *testing.T) {
+	tests := []struct {
+		name string
+		in   float64
+		// into points to the zero value decoded into
+		into interface{}
+		want interface{}
+		fail bool
+	}{
+		{"int8 max", 127, new(int8), int8(127), false},
+		{"int8 above max", 128, new(int8), nil, true},
+		{"int8 min", -128, new(int8), int8(-128), false},
+		{"int8 below min", -129, new(int8), nil, true},
+		{"int32 max", math.MaxInt32, new(int32), int32(math.MaxInt32), false},
+		{"int32 above max", math.MaxInt32 + 1, new(int32), nil, true},
+		{"int64 min", math.MinInt64, new(int64), int64(math.MinInt64), false},
+		{"int64 2^63", math.Ldexp(1, 63), new(int64), nil, true},
+		{"int64 1e20", 1e20, new(int64), nil, true},
+		{"int64 -1e20", -1e20, new(int64), nil, true},
+		{"int fraction", 2.5, new(int), nil, true},
+		{"uint8 max", 255, new(uint8), uint8(255), false},
+		{"uint8 above max", 256, new(uint8), nil, true},
+		{"uint negative", -1, new(uint), nil, true},
+		{"uint64 2^64", math.Ldexp(1, 64), new(uint64), nil, true},
+		{"uint64 1e20", 1e20, new(uint64), nil, true},
+		{"float32", 1.5, new(float32), float32(1.5), false},
+		{"float32 overflow", math.MaxFloat64, new(float32), nil, true},
+		{"single number into slice", 3, new([]int), []int{3}, false},
+		{"string", 3, new(string), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecodeValue(num(tt.in), tt.into)
+			if tt.fail {
+				var te *TypeError
+				if !errors.As(err, &te) {
+					t.Fatalf("expected a TypeError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := reflect.ValueOf(tt.into).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type glass struct {
+	Name string `df:"name"`
+}
+
+type drink struct {
+	Name  string `df:"name"`
+	Glass glass  `df:"glass"`
+	Count int    `df:"count"`
+}
+
+type search struct {
+	Alcohol         string   `df:"alcohol"`
+	AlcoholOriginal string   `df:"alcohol,original"`
+	Ingredients     []string `df:"ingredients"`
+	Drinks          []drink  `df:"drinks"`
+	Ignored         string   `df:"-"`
+	Plain           string
+	JSON            string `json:"json-key"`
+}
+
+func TestDecode(t *testing.T) {
+	s := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"alcohol":          str("rum"),
+		"alcohol.original": str("some rum"),
+		"ingredients":      list(str("lime"), str("mint")),
+		"drinks": list(
+			obj(map[string]*structpb.Value{"name": str("Mojito"), "glass": obj(map[string]*structpb.Value{"name": str("Highball")}), "count": num(2)}),
+			obj(map[string]*structpb.Value{"name": str("Daiquiri"), "count": str("")}),
+		),
+		"-":        str("ignored"),
+		"Ignored":  str("ignored"),
+		"Plain":    str("plain"),
+		"json-key": str("json"),
+		"unknown":  str("unknown"),
+	}}
+	want := search{
+		Alcohol:         "rum",
+		AlcoholOriginal: "some rum",
+		Ingredients:     []string{"lime", "mint"},
+		Drinks: []drink{
+			{Name: "Mojito", Glass: glass{Name: "Highball"}, Count: 2},
+			{Name: "Daiquiri"},
+		},
+		Plain: "plain",
+		JSON:  "json",
+	}
+	var got search
+	if err := Decode(s, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+
+	// A single value is accepted for a list
+	var single search
+	if err := Decode(&structpb.Struct{Fields: map[string]*structpb.Value{"ingredients": str("lime")}}, &single); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(single.Ingredients, []string{"lime"}) {
+		t.Errorf("got %v, expected [lime]", single.Ingredients)
+	}
+
+	if err := Decode(s, got); err == nil {
+		t.Error("expected an error decoding into a non-pointer")
+	}
+}
+
+func TestTypeError(t *testing.T) {
+	s := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"drinks": list(
+			obj(map[string]*structpb.Value{"name": str("Mojito")}),
+			obj(map[string]*structpb.Value{"glass": str("Highball")}),
+		),
+	}}
+	var got search
+	err := Decode(s, &got)
+	var te *TypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected a TypeError, got %v", err)
+	}
+	if te.Path != "drinks[1].glass" {
+		t.Errorf("got path %q, expected drinks[1].glass", te.Path)
+	}
+	want := `params: drinks[1].glass: cannot decode string "Highball" into params.glass`
+	if err.Error() != want {
+		t.Errorf("got %q, expected %q", err.Error(), want)
+	}
+
+	var a [2]string
+	if err := DecodeValue(list(str("a")), &a); err == nil || err.Error() != "params: (root): cannot decode list of 1 values into [2]string" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+type base struct {
+	ID   string `df:"id"`
+	Name string `df:"name"`
+}
+
+type embedding struct {
+	base
+	Name  string `df:"name"`
+	Glass string `df:"glass"`
+}
+
+func TestEmbedded(t *testing.T) {
+	s := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"id":    str("42"),
+		"name":  str("Mojito"),
+		"glass": str("Highball"),
+	}}
+	var got embedding
+	if err := Decode(s, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := embedding{base: base{ID: "42"}, Name: "Mojito", Glass: "Highball"}
+	if got != want {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+
+	enc, err := Encode(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(enc, s) {
+		t.Errorf("got %v, expected %v", enc, s)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	in := search{
+		Alcohol:         "rum",
+		AlcoholOriginal: "some rum",
+		Ingredients:     []string{"lime"},
+		Drinks:          []drink{{Name: "Mojito", Glass: glass{Name: "Highball"}, Count: 2}},
+		Ignored:         "ignored",
+	}
+	s, err := Encode(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Fields["alcohol.original"]; !ok {
+		t.Errorf("alcohol.original not encoded: %v", s)
+	}
+	var out search
+	if err := Decode(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Ignored = ""
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %+v, expected %+v", out, in)
+	}
+}
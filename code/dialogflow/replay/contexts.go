@@ -0,0 +1,140 @@
+package replay
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+)
+
+// contexts tracks the active contexts of a conversation the way Dialogflow
+// does: the output contexts returned by the webhook are sent back in the
+// following requests until their lifespan is exhausted. For Dialogflow CX
+// conversations the session parameters are tracked instead.
+type contexts struct {
+	active  map[string]map[string]interface{}
+	session map[string]interface{}
+}
+
+func newContexts() *contexts {
+	return &contexts{
+		active:  make(map[string]map[string]interface{}),
+		session: make(map[string]interface{}),
+	}
+}
+
+// inject adds the active contexts to the request, replacing the recorded ones
+// with the same name
+func (c *contexts) inject(req []byte) ([]byte, error) {
+	var err error
+	var r map[string]interface{}
+
+	if fulfillment.IsCX(req) {
+		return c.injectSession(req)
+	}
+	if len(c.active) == 0 {
+		return req, nil
+	}
+	if err = json.Unmarshal(req, &r); err != nil {
+		return nil, err
+	}
+	qr, _ := r["queryResult"].(map[string]interface{})
+	if qr == nil {
+		qr = map[string]interface{}{}
+		r["queryResult"] = qr
+	}
+
+	names := make([]string, 0, len(c.active))
+	for n := range c.active {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	ctxs := make([]interface{}, 0, len(names))
+	for _, n := range names {
+		ctxs = append(ctxs, c.active[n])
+	}
+	if recorded, ok := qr["outputContexts"].([]interface{}); ok {
+		for _, rc := range recorded {
+			if m, ok := rc.(map[string]interface{}); ok {
+				if n, _ := m["name"].(string); c.active[n] != nil {
+					continue
+				}
+			}
+			ctxs = append(ctxs, rc)
+		}
+	}
+	qr["outputContexts"] = ctxs
+	return json.Marshal(r)
+}
+
+// injectSession adds the session parameters to the CX request, the recorded
+// ones being kept unless the webhook changed them
+func (c *contexts) injectSession(req []byte) ([]byte, error) {
+	var r map[string]interface{}
+
+	if len(c.session) == 0 {
+		return req, nil
+	}
+	if err := json.Unmarshal(req, &r); err != nil {
+		return nil, err
+	}
+	si, _ := r["sessionInfo"].(map[string]interface{})
+	if si == nil {
+		si = map[string]interface{}{}
+		r["sessionInfo"] = si
+	}
+	ps, _ := si["parameters"].(map[string]interface{})
+	if ps == nil {
+		ps = map[string]interface{}{}
+		si["parameters"] = ps
+	}
+	for k, v := range c.session {
+		if v == nil {
+			delete(ps, k)
+			continue
+		}
+		ps[k] = v
+	}
+	return json.Marshal(r)
+}
+
+// update ages the active contexts by one turn and then applies the output
+// contexts of the response, a zero lifespan removing the context. The session
+// parameters updated by a CX response are kept as is, a nil value meaning
+// that the parameter was removed.
+func (c *contexts) update(body json.RawMessage) {
+	var cx struct {
+		SessionInfo struct {
+			Parameters map[string]interface{} `json:"parameters"`
+		} `json:"sessionInfo"`
+	}
+	if err := json.Unmarshal(body, &cx); err == nil {
+		for k, v := range cx.SessionInfo.Parameters {
+			c.session[k] = v
+		}
+	}
+
+	for n, ctx := range c.active {
+		l, _ := ctx["lifespanCount"].(float64)
+		if l <= 1 {
+			delete(c.active, n)
+			continue
+		}
+		ctx["lifespanCount"] = l - 1
+	}
+
+	var resp struct {
+		OutputContexts []map[string]interface{} `json:"outputContexts"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+	for _, ctx := range resp.OutputContexts {
+		n, _ := ctx["name"].(string)
+		if l, _ := ctx["lifespanCount"].(float64); l <= 0 {
+			delete(c.active, n)
+			continue
+		}
+		c.active[n] = ctx
+	}
+}
@@ -0,0 +1,80 @@
+package replay
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	goldenSuffix = ".golden.json"
+	drinksFile   = "drinks.json"
+)
+
+// Fixture is a recorded conversation. A fixture file either contains a single
+// WebhookRequest or a script, which is an object with a "turns" key holding
+// several WebhookRequest that will be played in order.
+type Fixture struct {
+	Name  string
+	Path  string
+	Turns []json.RawMessage
+}
+
+// Golden returns the path of the golden file associated to the fixture
+func (f Fixture) Golden() string {
+	return strings.TrimSuffix(f.Path, ".json") + goldenSuffix
+}
+
+type script struct {
+	Turns []json.RawMessage `json:"turns"`
+}
+
+// LoadFixture reads a single fixture file
+func LoadFixture(path string) (Fixture, error) {
+	var err error
+	var b []byte
+	var s script
+
+	f := Fixture{
+		Name: strings.TrimSuffix(filepath.Base(path), ".json"),
+		Path: path,
+	}
+	if b, err = os.ReadFile(path); err != nil {
+		return f, err
+	}
+	if err = json.Unmarshal(b, &s); err != nil {
+		return f, err
+	}
+	if s.Turns != nil {
+		f.Turns = s.Turns
+	} else {
+		f.Turns = []json.RawMessage{b}
+	}
+	return f, nil
+}
+
+// LoadFixtures reads all the fixtures found in dir, ignoring golden files and
+// the drinks file used by the fake source
+func LoadFixtures(dir string) ([]Fixture, error) {
+	var err error
+	var paths []string
+	var fs []Fixture
+
+	if paths, err = filepath.Glob(filepath.Join(dir, "*.json")); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if strings.HasSuffix(p, goldenSuffix) || filepath.Base(p) == drinksFile {
+			continue
+		}
+		f, err := LoadFixture(p)
+		if err != nil {
+			return nil, err
+		}
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
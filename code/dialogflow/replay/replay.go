@@ -0,0 +1,244 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/jinzhu/gorm/dialects/sqlite" // in memory store
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+	"github.com/Depado/articles/code/dialogflow/ssml"
+	"github.com/Depado/articles/code/dialogflow/store"
+	"github.com/Depado/articles/code/dialogflow/webhook"
+)
+
+// Turn is the recorded outcome of a single request, which is what ends up in
+// the golden files
+type Turn struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// Result is the outcome of replaying a single fixture
+type Result struct {
+	Name string
+	Diff string
+	Err  error
+}
+
+// Failed returns true if the fixture couldn't be played or doesn't match its
+// golden file
+func (r Result) Failed() bool {
+	return r.Err != nil || r.Diff != ""
+}
+
+// Runner replays fixtures against an http.Handler. A new handler is created
+// for every fixture so that fixtures don't depend on each other, the returned
+// function being called once the fixture is done.
+type Runner struct {
+	Handler func() (http.Handler, func(), error)
+	Path    string
+	Update  bool
+}
+
+// NewEngine returns a gin engine serving the webhook backed by the given
+// source, without any logging middleware
+func NewEngine(s cocktail.Source, o webhook.Options) *gin.Engine {
+	r := gin.New()
+	webhook.New(s, o).Register(r)
+	return r
+}
+
+// transitions are the Dialogflow CX transitions of the replayed webhook, so
+// that the CX fixtures cover them
+var transitions = map[string]string{
+	"recipe.start": "projects/cocktail-agent/locations/global/agents/cocktail/flows/00000000-0000-0000-0000-000000000000/pages/recipe",
+}
+
+// NewRunner returns a Runner replaying the fixtures of dir against the
+// webhook, using the drinks file of that directory as the cocktail source, the
+// shipped message catalog, an in memory store and the given codec. Since every
+// codec must give the same answers, they all share the same golden files.
+func NewRunner(dir string, update bool, codec fulfillment.Codec) (*Runner, error) {
+	s, err := NewFakeSource(filepath.Join(dir, drinksFile))
+	if err != nil {
+		return nil, err
+	}
+	c, err := i18n.Default()
+	if err != nil {
+		return nil, err
+	}
+	h := func() (http.Handler, func(), error) {
+		st, err := store.Open("sqlite3", ":memory:")
+		if err != nil {
+			return nil, nil, err
+		}
+		o := webhook.Options{Catalog: c, Store: st, Codec: codec, Transitions: transitions}
+		return NewEngine(&FakeSource{Drinks: s.Drinks}, o), func() { st.Close() }, nil
+	}
+	return &Runner{Handler: h, Path: "/webhook", Update: update}, nil
+}
+
+// Run replays all the fixtures found in dir
+func (r *Runner) Run(dir string) ([]Result, error) {
+	fs, err := LoadFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]Result, 0, len(fs))
+	for _, f := range fs {
+		rs = append(rs, r.RunFixture(f))
+	}
+	return rs, nil
+}
+
+// RunFixture plays all the turns of a fixture and compares the outcome with
+// the golden file, or writes it if the runner is in update mode
+func (r *Runner) RunFixture(f Fixture) Result {
+	var err error
+	var got []byte
+
+	res := Result{Name: f.Name}
+	h, done, err := r.Handler()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer done()
+	turns := make([]Turn, 0, len(f.Turns))
+	ctxs := newContexts()
+	for i, req := range f.Turns {
+		if req, err = ctxs.inject(req); err != nil {
+			res.Err = fmt.Errorf("turn %d: %v", i, err)
+			return res
+		}
+		t := r.play(h, req)
+		if err = checkSSML(t.Body); err != nil {
+			res.Err = fmt.Errorf("turn %d: %v", i, err)
+			return res
+		}
+		ctxs.update(t.Body)
+		turns = append(turns, t)
+	}
+
+	if got, err = marshal(turns); err != nil {
+		res.Err = err
+		return res
+	}
+
+	if r.Update {
+		res.Err = os.WriteFile(f.Golden(), got, 0644)
+		return res
+	}
+
+	want, err := os.ReadFile(f.Golden())
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Diff = diff(string(want), string(got))
+	return res
+}
+
+// play sends a single request to the handler and normalizes the response body
+// so that the golden files don't depend on the key order
+func (r *Runner) play(h http.Handler, req []byte) Turn {
+	w := httptest.NewRecorder()
+	hr := httptest.NewRequest(http.MethodPost, r.Path, bytes.NewReader(req))
+	hr.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(w, hr)
+
+	t := Turn{Status: w.Code, Body: json.RawMessage("null")}
+	var v interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err == nil {
+		if b, err := marshal(v); err == nil {
+			t.Body = b
+		}
+	}
+	return t
+}
+
+// marshal returns the indented JSON encoding of v without escaping the HTML
+// characters, which keeps the SSML readable in the golden files
+func marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// checkSSML validates every "ssml" field found in the response body
+func checkSSML(body json.RawMessage) error {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	return walkSSML(v)
+}
+
+func walkSSML(v interface{}) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, c := range t {
+			if s, ok := c.(string); ok && k == "ssml" {
+				if err := ssml.Validate(s); err != nil {
+					return fmt.Errorf("invalid ssml: %v", err)
+				}
+				continue
+			}
+			if err := walkSSML(c); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, c := range t {
+			if err := walkSSML(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// diff returns a line by line comparison of want and got, or an empty string
+// if they are identical
+func diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w == g {
+			continue
+		}
+		if i < len(wl) {
+			fmt.Fprintf(&b, "-%4d %s\n", i+1, w)
+		}
+		if i < len(gl) {
+			fmt.Fprintf(&b, "+%4d %s\n", i+1, g)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package replay
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+)
+
+var update = flag.Bool("update", false, "update the golden files instead of comparing")
+
+const testdata = "../testdata/replay"
+
+func TestReplay(t *testing.T) {
+	fs, err := LoadFixtures(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRunner(testdata, *update, fulfillment.Leboncoin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fs {
+		f := f
+		t.Run(f.Name, func(t *testing.T) {
+			res := r.RunFixture(f)
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			if res.Diff != "" {
+				t.Errorf("response doesn't match %s, run with -update to rewrite it:\n%s", f.Golden(), res.Diff)
+			}
+		})
+	}
+}
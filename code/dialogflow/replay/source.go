@@ -0,0 +1,74 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+)
+
+// FakeSource is a cocktail.Source serving drinks from a fixed list so that
+// replayed conversations always get the same answers
+type FakeSource struct {
+	sync.Mutex
+	Drinks []*cocktail.FullDrink
+	next   int
+}
+
+// NewFakeSource loads a FakeSource from a JSON file using the same format as
+// the cocktail API (a FullDrinkList), see cocktail.LoadDrinks
+func NewFakeSource(path string) (*FakeSource, error) {
+	ds, err := cocktail.LoadDrinks(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FakeSource{Drinks: ds}, nil
+}
+
+// GetRandomDrink returns the drinks of the list one after the other, looping
+// back to the first one when the end of the list is reached
+func (f *FakeSource) GetRandomDrink(ctx context.Context) (*cocktail.FullDrink, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if len(f.Drinks) == 0 {
+		return nil, errors.New("no drink in fake source")
+	}
+	d := f.Drinks[f.next%len(f.Drinks)]
+	f.next++
+	return d, nil
+}
+
+// LookupDrink returns the drink with the given ID
+func (f *FakeSource) LookupDrink(ctx context.Context, id string) (*cocktail.FullDrink, error) {
+	for _, d := range f.Drinks {
+		if d.IDDrink == id {
+			return d, nil
+		}
+	}
+	return nil, cocktail.ErrNotFound
+}
+
+// SearchDrinks returns the drinks whose name contains the given string,
+// ignoring case
+func (f *FakeSource) SearchDrinks(ctx context.Context, name string) ([]*cocktail.FullDrink, error) {
+	var ds []*cocktail.FullDrink
+	for _, d := range f.Drinks {
+		if strings.Contains(strings.ToLower(d.StrDrink), strings.ToLower(name)) {
+			ds = append(ds, d)
+		}
+	}
+	return ds, nil
+}
+
+// Ping always succeeds since the drinks are already loaded
+func (f *FakeSource) Ping(ctx context.Context) error {
+	return nil
+}
+
+// List returns the values of the list found in the drinks
+func (f *FakeSource) List(ctx context.Context, l cocktail.List) ([]string, error) {
+	return cocktail.Drinks(f.Drinks).List(ctx, l)
+}
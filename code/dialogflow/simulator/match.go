@@ -0,0 +1,275 @@
+package simulator
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+// Threshold is the minimum score for an intent to be matched, below it the
+// fallback intent is used
+const Threshold = 0.3
+
+// contextBonus favors the intents whose input contexts are active, as
+// Dialogflow does
+const contextBonus = 0.1
+
+var (
+	nonWord = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	number  = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+)
+
+// tokenize lower cases the text and splits it into words
+func tokenize(s string) []string {
+	return strings.Fields(nonWord.ReplaceAllString(strings.ToLower(s), " "))
+}
+
+// Match is the outcome of the intent detection
+type Match struct {
+	Intent     *agent.Intent
+	Score      float64
+	Params     map[string]interface{}
+	Originals  map[string]string
+	QueryText  string
+	IsFallback bool
+}
+
+// value is an entity value found in the user text
+type value struct {
+	value    string
+	original string
+	tokens   []string
+}
+
+// findEntity looks for the longest synonym of the entity in the tokens
+func findEntity(e *agent.Entity, lang string, tokens []string) (value, bool) {
+	var best value
+	found := false
+	for _, en := range e.Entries[lang] {
+		syns := append([]string{en.Value}, en.Synonyms...)
+		for _, syn := range syns {
+			st := tokenize(syn)
+			if len(st) == 0 || len(st) <= len(best.tokens) {
+				continue
+			}
+			if i := indexTokens(tokens, st); i >= 0 {
+				best = value{value: en.Value, original: strings.Join(tokens[i:i+len(st)], " "), tokens: st}
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// indexTokens returns the index of sub in tokens, or -1
+func indexTokens(tokens, sub []string) int {
+	for i := 0; i+len(sub) <= len(tokens); i++ {
+		ok := true
+		for j := range sub {
+			if tokens[i+j] != sub[j] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeTokens removes the first occurrence of sub from tokens
+func removeTokens(tokens, sub []string) []string {
+	i := indexTokens(tokens, sub)
+	if i < 0 {
+		return tokens
+	}
+	out := append([]string{}, tokens[:i]...)
+	return append(out, tokens[i+len(sub):]...)
+}
+
+// extract finds the value of an entity type, custom or system, in the tokens
+func (s *Session) extract(dataType string, tokens []string) (value, bool) {
+	name := strings.TrimPrefix(dataType, "@")
+	switch name {
+	case "sys.number", "sys.number-integer", "sys.cardinal":
+		for _, t := range tokens {
+			if number.MatchString(t) {
+				return value{value: t, original: t, tokens: []string{t}}, true
+			}
+		}
+		return value{}, false
+	}
+	if e := s.Agent.Entity(name); e != nil {
+		return findEntity(e, s.Lang, tokens)
+	}
+	return value{}, false
+}
+
+// jaccard returns the similarity of two sets of tokens
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	set := make(map[string]int)
+	for _, t := range a {
+		set[t] |= 1
+	}
+	for _, t := range b {
+		set[t] |= 2
+	}
+	inter := 0
+	for _, v := range set {
+		if v == 3 {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(set))
+}
+
+// scorePhrase scores a training phrase against the user tokens and returns
+// the entity values it captured, keyed by parameter name
+func (s *Session) scorePhrase(us agent.UserSays, tokens []string) (float64, map[string]value) {
+	values := make(map[string]value)
+	remaining := tokens
+	var literal []string
+	var any string
+	missing := 0
+
+	for _, p := range us.Data {
+		if p.Meta == "" || p.Alias == "" {
+			literal = append(literal, tokenize(p.Text)...)
+			continue
+		}
+		if p.Meta == "@sys.any" {
+			any = p.Alias
+			continue
+		}
+		v, ok := s.extract(p.Meta, remaining)
+		if !ok {
+			missing++
+			continue
+		}
+		values[p.Alias] = v
+		remaining = removeTokens(remaining, v.tokens)
+	}
+
+	// A single @sys.any part captures whatever isn't part of the phrase
+	if any != "" {
+		lit := make(map[string]bool)
+		for _, t := range literal {
+			lit[t] = true
+		}
+		var rest []string
+		for _, t := range remaining {
+			if !lit[t] {
+				rest = append(rest, t)
+			}
+		}
+		if len(rest) > 0 {
+			values[any] = value{value: strings.Join(rest, " "), original: strings.Join(rest, " "), tokens: rest}
+			remaining = removeTokens(remaining, rest)
+		} else {
+			missing++
+		}
+	}
+
+	score := jaccard(remaining, literal)
+	if missing > 0 {
+		score /= float64(1 + missing)
+	}
+	return score, values
+}
+
+// detect returns the best matching intent for the text given the active
+// contexts
+func (s *Session) detect(text string) Match {
+	tokens := tokenize(text)
+	best := Match{QueryText: text}
+	var bestValues map[string]value
+
+	for _, in := range s.Agent.Intents {
+		if in.FallbackIntent || !s.contextsActive(in.Contexts) {
+			continue
+		}
+		for _, us := range in.UserSays[s.Lang] {
+			score, values := s.scorePhrase(us, tokens)
+			if len(in.Contexts) > 0 {
+				score += contextBonus
+			}
+			if score > best.Score || (score == best.Score && best.Intent != nil && in.Priority > best.Intent.Priority) {
+				best.Intent, best.Score, bestValues = in, score, values
+			}
+		}
+	}
+
+	if best.Intent == nil || best.Score < Threshold {
+		best = Match{QueryText: text, IsFallback: true, Intent: s.fallback()}
+		bestValues = nil
+	}
+	if best.Score > 1 {
+		best.Score = 1
+	}
+	if best.Intent != nil {
+		best.Params, best.Originals = s.parameters(best.Intent, tokens, bestValues)
+	}
+	return best
+}
+
+// fallback returns the fallback intent whose input contexts are active,
+// preferring the most specific one
+func (s *Session) fallback() *agent.Intent {
+	var fb *agent.Intent
+	for _, in := range s.Agent.Intents {
+		if in.FallbackIntent && s.contextsActive(in.Contexts) && (fb == nil || len(in.Contexts) > len(fb.Contexts)) {
+			fb = in
+		}
+	}
+	return fb
+}
+
+// parameters resolves the intent parameters from the captured values,
+// looking for custom entities in the whole text when the training phrase
+// didn't capture them and resolving references to context parameters
+func (s *Session) parameters(in *agent.Intent, tokens []string, values map[string]value) (map[string]interface{}, map[string]string) {
+	params := make(map[string]interface{})
+	originals := make(map[string]string)
+	if len(in.Responses) == 0 {
+		return params, originals
+	}
+
+	ps := append([]agent.Parameter{}, in.Responses[0].Parameters...)
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	for _, p := range ps {
+		params[p.Name] = ""
+		originals[p.Name] = ""
+
+		switch {
+		case strings.HasPrefix(p.Value, "#"):
+			ref := strings.SplitN(strings.TrimPrefix(p.Value, "#"), ".", 2)
+			if len(ref) == 2 {
+				if c, ok := s.contexts[strings.ToLower(ref[0])]; ok {
+					if v, ok := c.params[ref[1]]; ok {
+						params[p.Name] = v
+						originals[p.Name], _ = c.params[ref[1]+".original"].(string)
+					}
+				}
+			}
+		default:
+			v, ok := values[p.Name]
+			if !ok && p.DataType != "@sys.any" {
+				v, ok = s.extract(p.DataType, tokens)
+			}
+			if ok {
+				params[p.Name] = v.value
+				originals[p.Name] = v.original
+			}
+		}
+		if params[p.Name] == "" && p.DefaultValue != "" {
+			params[p.Name] = p.DefaultValue
+		}
+	}
+	return params, originals
+}
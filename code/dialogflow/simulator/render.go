@@ -0,0 +1,110 @@
+package simulator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// response is the part of the webhook response the simulator cares about
+type response struct {
+	FulfillmentText     string    `json:"fulfillmentText"`
+	FulfillmentMessages []message `json:"fulfillmentMessages"`
+	OutputContexts      []struct {
+		Name          string                 `json:"name"`
+		LifespanCount *int                   `json:"lifespanCount"`
+		Parameters    map[string]interface{} `json:"parameters"`
+	} `json:"outputContexts"`
+}
+
+type title struct {
+	Title string `json:"title"`
+}
+
+// message is a rich message, only one of the fields being set
+type message struct {
+	Platform string `json:"platform"`
+	Text     *struct {
+		Text []string `json:"text"`
+	} `json:"text"`
+	SimpleResponses *struct {
+		SimpleResponses []struct {
+			DisplayText  string `json:"displayText"`
+			TextToSpeech string `json:"textToSpeech"`
+		} `json:"simpleResponses"`
+	} `json:"simpleResponses"`
+	BasicCard *struct {
+		Title         string `json:"title"`
+		Subtitle      string `json:"subtitle"`
+		FormattedText string `json:"formattedText"`
+	} `json:"basicCard"`
+	Card *struct {
+		Title    string `json:"title"`
+		Subtitle string `json:"subtitle"`
+	} `json:"card"`
+	Suggestions *struct {
+		Suggestions []title `json:"suggestions"`
+	} `json:"suggestions"`
+	QuickReplies *struct {
+		Title        string   `json:"title"`
+		QuickReplies []string `json:"quickReplies"`
+	} `json:"quickReplies"`
+}
+
+// render returns the lines displayed for the response, skipping the texts
+// already displayed since the same text is usually sent for every platform
+func (r response) render() []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+
+	add(r.FulfillmentText)
+	for _, m := range r.FulfillmentMessages {
+		switch {
+		case m.Text != nil:
+			for _, t := range m.Text.Text {
+				add(t)
+			}
+		case m.SimpleResponses != nil:
+			for _, sr := range m.SimpleResponses.SimpleResponses {
+				if sr.DisplayText != "" {
+					add(sr.DisplayText)
+				} else {
+					add(sr.TextToSpeech)
+				}
+			}
+		case m.BasicCard != nil:
+			add(card(m.BasicCard.Title, m.BasicCard.Subtitle))
+		case m.Card != nil:
+			add(card(m.Card.Title, m.Card.Subtitle))
+		case m.Suggestions != nil:
+			ts := make([]string, 0, len(m.Suggestions.Suggestions))
+			for _, s := range m.Suggestions.Suggestions {
+				ts = append(ts, s.Title)
+			}
+			add(chips(ts))
+		case m.QuickReplies != nil:
+			add(m.QuickReplies.Title)
+			add(chips(m.QuickReplies.QuickReplies))
+		}
+	}
+	return out
+}
+
+func card(title, subtitle string) string {
+	if subtitle == "" {
+		return fmt.Sprintf("[card] %s", title)
+	}
+	return fmt.Sprintf("[card] %s - %s", title, subtitle)
+}
+
+func chips(ts []string) string {
+	if len(ts) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(ts, "] [") + "]"
+}
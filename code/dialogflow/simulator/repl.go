@@ -0,0 +1,74 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const prompt = "> "
+
+// REPL reads the user input line by line from r, sends it to the session and
+// writes the rendered replies to w. Lines starting with a colon are commands:
+// ":contexts" lists the active contexts, ":reset" clears them and ":quit"
+// ends the session.
+func (s *Session) REPL(ctx context.Context, r io.Reader, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	fmt.Fprint(w, prompt)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch line {
+		case "":
+		case ":quit", ":q":
+			return nil
+		case ":reset":
+			s.Reset()
+			fmt.Fprintln(w, "contexts cleared")
+		case ":contexts":
+			s.printContexts(w)
+		default:
+			rep, err := s.Send(ctx, line)
+			printReply(w, rep, err)
+		}
+		fmt.Fprint(w, prompt)
+	}
+	fmt.Fprintln(w)
+	return sc.Err()
+}
+
+func (s *Session) printContexts(w io.Writer) {
+	if len(s.contexts) == 0 {
+		fmt.Fprintln(w, "no active context")
+		return
+	}
+	names := make([]string, 0, len(s.contexts))
+	for n := range s.contexts {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		b, _ := json.Marshal(s.contexts[n].params)
+		fmt.Fprintf(w, "%s (%d) %s\n", n, s.contexts[n].lifespan, b)
+	}
+}
+
+func printReply(w io.Writer, r *Reply, err error) {
+	if r != nil {
+		if r.Match.Intent == nil {
+			fmt.Fprintln(w, "# no intent matched")
+		} else {
+			b, _ := json.Marshal(r.Match.Params)
+			fmt.Fprintf(w, "# intent %q (%.2f) action %q params %s\n", r.Match.Intent.Name, r.Match.Score, r.Action, b)
+		}
+		for _, m := range r.Messages {
+			fmt.Fprintln(w, m)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(w, "# error: %v\n", err)
+	}
+}
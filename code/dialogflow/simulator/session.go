@@ -0,0 +1,261 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	df "github.com/leboncoin/dialogflow-go-webhook"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+// DefaultLifespan is the lifespan of the contexts returned by the webhook
+// without one, as Dialogflow does
+const DefaultLifespan = 5
+
+// Session is a conversation with the agent. It keeps track of the active
+// contexts between turns and sends the matched intents to the webhook when
+// they have fulfillment enabled.
+type Session struct {
+	Agent  *agent.Agent
+	Lang   string
+	URL    string
+	Client *http.Client
+	ID     string
+
+	contexts map[string]*activeContext
+}
+
+// activeContext is a context along with its remaining lifespan
+type activeContext struct {
+	lifespan int
+	params   map[string]interface{}
+}
+
+// NewSession returns a new session with the given agent sending the webhook
+// requests to url. An empty url disables the fulfillment.
+func NewSession(a *agent.Agent, lang, url string) *Session {
+	if lang == "" {
+		lang = a.Meta.Language
+	}
+	if lang == "" {
+		lang = "en"
+	}
+	return &Session{
+		Agent:    a,
+		Lang:     lang,
+		URL:      url,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		ID:       fmt.Sprintf("simulator-%d", time.Now().Unix()),
+		contexts: make(map[string]*activeContext),
+	}
+}
+
+// Reply is the outcome of a single turn
+type Reply struct {
+	Match    Match
+	Action   string
+	Webhook  bool
+	Status   int
+	Messages []string
+}
+
+// Reset removes all the active contexts
+func (s *Session) Reset() {
+	s.contexts = make(map[string]*activeContext)
+}
+
+// name returns the full name of a context in this session
+func (s *Session) name(ctx string) string {
+	return "projects/simulator/agent/sessions/" + s.ID + "/contexts/" + ctx
+}
+
+// contextsActive returns true if all the given contexts are active
+func (s *Session) contextsActive(names []string) bool {
+	for _, n := range names {
+		if _, ok := s.contexts[strings.ToLower(n)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Send detects the intent of text, updates the contexts and calls the
+// webhook if the intent uses it
+func (s *Session) Send(ctx context.Context, text string) (*Reply, error) {
+	m := s.detect(text)
+	r := &Reply{Match: m}
+	if m.Intent == nil {
+		return r, nil
+	}
+	r.Action = m.Intent.Action()
+
+	params := make(map[string]interface{}, len(m.Params)*2)
+	for k, v := range m.Params {
+		params[k] = v
+		params[k+".original"] = m.Originals[k]
+	}
+	s.age()
+	if len(m.Intent.Responses) > 0 {
+		resp := m.Intent.Responses[0]
+		if resp.ResetContexts {
+			s.Reset()
+		}
+		for _, ac := range resp.AffectedContexts {
+			s.set(strings.ToLower(ac.Name), ac.Lifespan, params)
+		}
+	}
+
+	if !m.Intent.WebhookUsed || s.URL == "" {
+		r.Messages = s.static(m)
+		return r, nil
+	}
+	r.Webhook = true
+	return r, s.fulfill(ctx, r, m)
+}
+
+// age decreases the lifespan of the active contexts, removing the exhausted
+// ones
+func (s *Session) age() {
+	for n, c := range s.contexts {
+		if c.lifespan--; c.lifespan <= 0 {
+			delete(s.contexts, n)
+		}
+	}
+}
+
+// set activates a context, merging the parameters with the existing ones. A
+// zero lifespan removes the context.
+func (s *Session) set(name string, lifespan int, params map[string]interface{}) {
+	if lifespan <= 0 {
+		delete(s.contexts, name)
+		return
+	}
+	c, ok := s.contexts[name]
+	if !ok {
+		c = &activeContext{params: make(map[string]interface{})}
+		s.contexts[name] = c
+	}
+	c.lifespan = lifespan
+	for k, v := range params {
+		c.params[k] = v
+	}
+}
+
+// outputContexts returns the active contexts sorted by name
+func (s *Session) outputContexts() (df.Contexts, error) {
+	names := make([]string, 0, len(s.contexts))
+	for n := range s.contexts {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	out := make(df.Contexts, 0, len(names))
+	for _, n := range names {
+		b, err := json.Marshal(s.contexts[n].params)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &df.Context{Name: s.name(n), LifespanCount: s.contexts[n].lifespan, Parameters: b})
+	}
+	return out, nil
+}
+
+// request builds the webhook request for the match
+func (s *Session) request(m Match) (*df.Request, error) {
+	params, err := json.Marshal(m.Params)
+	if err != nil {
+		return nil, err
+	}
+	ctxs, err := s.outputContexts()
+	if err != nil {
+		return nil, err
+	}
+	return &df.Request{
+		Session:    "projects/simulator/agent/sessions/" + s.ID,
+		ResponseID: fmt.Sprintf("%s-%d", s.ID, time.Now().UnixNano()),
+		QueryResult: df.QueryResult{
+			QueryText:                 m.QueryText,
+			Parameters:                params,
+			AllRequiredParamsPresent:  true,
+			OutputContexts:            ctxs,
+			Intent:                    df.Intent{Name: "projects/simulator/agent/intents/" + m.Intent.ID, DisplayName: m.Intent.Name},
+			IntentDetectionConfidence: m.Score,
+			LanguageCode:              s.Lang,
+			Action:                    m.Intent.Action(),
+		},
+	}, nil
+}
+
+// fulfill sends the request to the webhook, applies the output contexts of
+// the response and renders its messages
+func (s *Session) fulfill(ctx context.Context, r *Reply, m Match) error {
+	dfr, err := s.request(m)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(dfr)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldn't call webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	r.Status = resp.StatusCode
+	if b, err = io.ReadAll(resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook answered %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	var wr response
+	if err = json.Unmarshal(b, &wr); err != nil {
+		return fmt.Errorf("couldn't decode webhook response: %v", err)
+	}
+	for _, c := range wr.OutputContexts {
+		l := DefaultLifespan
+		if c.LifespanCount != nil {
+			l = *c.LifespanCount
+		}
+		s.set(strings.ToLower(path.Base(c.Name)), l, c.Parameters)
+	}
+	r.Messages = wr.render()
+	return nil
+}
+
+// static returns the static responses of the intent in the session language,
+// with the parameter references replaced by their values
+func (s *Session) static(m Match) []string {
+	if len(m.Intent.Responses) == 0 {
+		return nil
+	}
+	var out []string
+	for _, msg := range m.Intent.Responses[0].Messages {
+		if msg.Lang != "" && msg.Lang != s.Lang {
+			continue
+		}
+		if ts := msg.Texts(); len(ts) > 0 {
+			t := ts[0]
+			for k, v := range m.Params {
+				t = strings.Replace(t, "$"+k, fmt.Sprint(v), -1)
+			}
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Depado/articles/code/dialogflow/agent"
+)
+
+// testAgent is a small cocktail agent: a search with its follow-up intent, a
+// static random intent and the fallback
+func testAgent() *agent.Agent {
+	alcohol := agent.NewParameter("alcohol", "@alcohol", false)
+	followup := []agent.AffectedContext{{Name: "search-followup", Lifespan: 2}}
+	return &agent.Agent{
+		Meta: agent.Meta{Language: "en"},
+		Entities: []*agent.Entity{{
+			Name: "alcohol",
+			Entries: map[string][]agent.Entry{"en": {
+				agent.NewEntry("Rum", "rum"),
+				agent.NewEntry("Light rum", "light rum", "white rum"),
+				agent.NewEntry("Gin", "gin"),
+			}},
+		}},
+		Intents: []*agent.Intent{
+			{
+				ID:          "search-id",
+				Name:        "search",
+				WebhookUsed: true,
+				Responses: []agent.Response{{
+					Action:           "search",
+					AffectedContexts: followup,
+					Parameters:       []agent.Parameter{alcohol},
+					Messages:         []agent.Message{agent.TextMessage("en", "Looking for $alcohol")},
+				}},
+				UserSays: map[string][]agent.UserSays{"en": {
+					agent.Phrase(agent.T("find me a cocktail with "), agent.E("rum", "@alcohol", "alcohol")),
+					agent.Phrase(agent.T("i want a drink with "), agent.E("gin", "@alcohol", "alcohol")),
+				}},
+			},
+			{
+				ID:          "specify-id",
+				Name:        "search - specify",
+				Contexts:    []string{"search-followup"},
+				WebhookUsed: true,
+				Responses: []agent.Response{{
+					Action:           "search.specify",
+					AffectedContexts: followup,
+					Parameters: []agent.Parameter{
+						alcohol,
+						{Name: "previous", DataType: "@alcohol", Value: "#search-followup.alcohol"},
+					},
+					Messages: []agent.Message{agent.TextMessage("en", "$previous or $alcohol")},
+				}},
+				UserSays: map[string][]agent.UserSays{"en": {
+					agent.Phrase(agent.T("with "), agent.E("gin", "@alcohol", "alcohol")),
+				}},
+			},
+			{
+				ID:        "random-id",
+				Name:      "random",
+				Responses: []agent.Response{{Action: "random", Messages: []agent.Message{agent.TextMessage("en", "Here's a random one")}}},
+				UserSays:  map[string][]agent.UserSays{"en": agent.Phrases("surprise me", "give me a random cocktail")},
+			},
+			{
+				ID:             "fallback-id",
+				Name:           "Default Fallback Intent",
+				FallbackIntent: true,
+				Responses:      []agent.Response{{Action: "input.unknown", Messages: []agent.Message{agent.TextMessage("en", "Sorry?")}}},
+			},
+		},
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		contexts  map[string]map[string]interface{}
+		intent    string
+		params    map[string]interface{}
+		originals map[string]string
+	}{
+		{
+			name:      "entity synonym",
+			text:      "Find me a cocktail with white rum!",
+			intent:    "search",
+			params:    map[string]interface{}{"alcohol": "Light rum"},
+			originals: map[string]string{"alcohol": "white rum"},
+		},
+		{
+			name:      "other phrase",
+			text:      "i want a drink with rum please",
+			intent:    "search",
+			params:    map[string]interface{}{"alcohol": "Rum"},
+			originals: map[string]string{"alcohol": "rum"},
+		},
+		{
+			name:      "no parameter",
+			text:      "surprise me",
+			intent:    "random",
+			params:    map[string]interface{}{},
+			originals: map[string]string{},
+		},
+		{
+			name:      "inactive context",
+			text:      "with gin",
+			intent:    "Default Fallback Intent",
+			params:    map[string]interface{}{},
+			originals: map[string]string{},
+		},
+		{
+			name:      "active context",
+			text:      "with gin",
+			contexts:  map[string]map[string]interface{}{"search-followup": {"alcohol": "Rum", "alcohol.original": "rum"}},
+			intent:    "search - specify",
+			params:    map[string]interface{}{"alcohol": "Gin", "previous": "Rum"},
+			originals: map[string]string{"alcohol": "gin", "previous": "rum"},
+		},
+		{
+			name:      "unrelated",
+			text:      "what's the weather like",
+			intent:    "Default Fallback Intent",
+			params:    map[string]interface{}{},
+			originals: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSession(testAgent(), "", "")
+			for n, ps := range tt.contexts {
+				s.set(n, 1, ps)
+			}
+			m := s.detect(tt.text)
+			if m.Intent == nil || m.Intent.Name != tt.intent {
+				t.Fatalf("got intent %v, expected %s", m.Intent, tt.intent)
+			}
+			if m.IsFallback != (tt.intent == "Default Fallback Intent") {
+				t.Errorf("got fallback %v", m.IsFallback)
+			}
+			if !reflect.DeepEqual(m.Params, tt.params) || !reflect.DeepEqual(m.Originals, tt.originals) {
+				t.Errorf("got parameters %v and originals %v, expected %v and %v", m.Params, m.Originals, tt.params, tt.originals)
+			}
+			if m.Score < 0 || m.Score > 1 {
+				t.Errorf("got score %f", m.Score)
+			}
+		})
+	}
+}
+
+func TestScorePhrase(t *testing.T) {
+	s := NewSession(testAgent(), "", "")
+	us := s.Agent.Intent("search").UserSays["en"][0]
+	tests := []struct {
+		text   string
+		score  float64
+		values map[string]string
+	}{
+		{"find me a cocktail with rum", 1, map[string]string{"alcohol": "Rum"}},
+		{"find me a cocktail with some rum", 5.0 / 6, map[string]string{"alcohol": "Rum"}},
+		// A missing entity halves the score
+		{"find me a cocktail with vodka", 5.0 / 6 / 2, map[string]string{}},
+		{"rum", 0, map[string]string{"alcohol": "Rum"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			score, values := s.scorePhrase(us, tokenize(tt.text))
+			if math.Abs(score-tt.score) > 1e-9 {
+				t.Errorf("got score %f, expected %f", score, tt.score)
+			}
+			got := make(map[string]string, len(values))
+			for k, v := range values {
+				got[k] = v.value
+			}
+			if !reflect.DeepEqual(got, tt.values) {
+				t.Errorf("got values %v, expected %v", got, tt.values)
+			}
+		})
+	}
+}
+
+// lifespans returns the remaining lifespan of the active contexts
+func lifespans(s *Session) map[string]int {
+	ls := make(map[string]int, len(s.contexts))
+	for n, c := range s.contexts {
+		ls[n] = c.lifespan
+	}
+	return ls
+}
+
+func TestContextLifespan(t *testing.T) {
+	ctx := context.Background()
+	s := NewSession(testAgent(), "en", "")
+	turns := []struct {
+		text      string
+		intent    string
+		messages  []string
+		lifespans map[string]int
+	}{
+		{"find me a cocktail with rum", "search", []string{"Looking for Rum"}, map[string]int{"search-followup": 2}},
+		{"surprise me", "random", []string{"Here's a random one"}, map[string]int{"search-followup": 1}},
+		// Still active when the intent is detected, then set again
+		{"with gin", "search - specify", []string{"Rum or Gin"}, map[string]int{"search-followup": 2}},
+		{"surprise me", "random", []string{"Here's a random one"}, map[string]int{"search-followup": 1}},
+		{"surprise me", "random", []string{"Here's a random one"}, map[string]int{}},
+		{"with gin", "Default Fallback Intent", []string{"Sorry?"}, map[string]int{}},
+	}
+	for i, tt := range turns {
+		r, err := s.Send(ctx, tt.text)
+		if err != nil {
+			t.Fatalf("turn %d: %v", i, err)
+		}
+		if r.Match.Intent.Name != tt.intent || r.Webhook {
+			t.Errorf("turn %d: got intent %s and webhook %v, expected %s without webhook", i, r.Match.Intent.Name, r.Webhook, tt.intent)
+		}
+		if !reflect.DeepEqual(r.Messages, tt.messages) {
+			t.Errorf("turn %d: got messages %q, expected %q", i, r.Messages, tt.messages)
+		}
+		if got := lifespans(s); !reflect.DeepEqual(got, tt.lifespans) {
+			t.Errorf("turn %d: got lifespans %v, expected %v", i, got, tt.lifespans)
+		}
+	}
+}
+
+// webhookRequest is the part of the webhook request checked by the tests
+type webhookRequest struct {
+	Session     string `json:"session"`
+	QueryResult struct {
+		QueryText      string                 `json:"queryText"`
+		Action         string                 `json:"action"`
+		Parameters     map[string]interface{} `json:"parameters"`
+		LanguageCode   string                 `json:"languageCode"`
+		OutputContexts []struct {
+			Name          string                 `json:"name"`
+			LifespanCount int                    `json:"lifespanCount"`
+			Parameters    map[string]interface{} `json:"parameters"`
+		} `json:"outputContexts"`
+		Intent struct {
+			Name        string `json:"name"`
+			DisplayName string `json:"displayName"`
+		} `json:"intent"`
+	} `json:"queryResult"`
+}
+
+func TestFulfill(t *testing.T) {
+	var got webhookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{
+			"fulfillmentText": "Here is a Mojito",
+			"fulfillmentMessages": [{"quickReplies": {"title": "What now?", "quickReplies": ["Recipe"]}}],
+			"outputContexts": [
+				{"name": "` + got.Session + `/contexts/recipe", "lifespanCount": 3, "parameters": {"drink": "11000"}},
+				{"name": "` + got.Session + `/contexts/search-followup", "lifespanCount": 0},
+				{"name": "` + got.Session + `/contexts/Other"}
+			]
+		}`))
+	}))
+	defer srv.Close()
+
+	s := NewSession(testAgent(), "en", srv.URL)
+	s.set("previous", 3, map[string]interface{}{"step": 1})
+	r, err := s.Send(context.Background(), "find me a cocktail with rum")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := "projects/simulator/agent/sessions/" + s.ID
+	q := got.QueryResult
+	if got.Session != session || q.Action != "search" || q.LanguageCode != "en" || q.QueryText != "find me a cocktail with rum" {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if q.Intent.Name != "projects/simulator/agent/intents/search-id" || q.Intent.DisplayName != "search" {
+		t.Errorf("unexpected intent %+v", q.Intent)
+	}
+	if !reflect.DeepEqual(q.Parameters, map[string]interface{}{"alcohol": "Rum"}) {
+		t.Errorf("unexpected parameters %v", q.Parameters)
+	}
+	if len(q.OutputContexts) != 2 {
+		t.Fatalf("got %d contexts, expected 2: %+v", len(q.OutputContexts), q.OutputContexts)
+	}
+	for i, want := range []struct {
+		name     string
+		lifespan int
+	}{{"previous", 2}, {"search-followup", 2}} {
+		if c := q.OutputContexts[i]; c.Name != session+"/contexts/"+want.name || c.LifespanCount != want.lifespan {
+			t.Errorf("got context %s with lifespan %d, expected %s with %d", c.Name, c.LifespanCount, want.name, want.lifespan)
+		}
+	}
+	if ps := q.OutputContexts[1].Parameters; ps["alcohol"] != "Rum" || ps["alcohol.original"] != "rum" {
+		t.Errorf("unexpected context parameters %v", ps)
+	}
+
+	// The contexts of the response replace the ones of the request
+	if !r.Webhook || r.Status != http.StatusOK {
+		t.Errorf("got webhook %v and status %d", r.Webhook, r.Status)
+	}
+	if want := []string{"Here is a Mojito", "What now?", "[Recipe]"}; !reflect.DeepEqual(r.Messages, want) {
+		t.Errorf("got messages %q, expected %q", r.Messages, want)
+	}
+	if want := map[string]int{"previous": 2, "recipe": 3, "other": DefaultLifespan}; !reflect.DeepEqual(lifespans(s), want) {
+		t.Errorf("got lifespans %v, expected %v", lifespans(s), want)
+	}
+	if s.contexts["recipe"].params["drink"] != "11000" {
+		t.Errorf("unexpected recipe parameters %v", s.contexts["recipe"].params)
+	}
+}
@@ -0,0 +1,236 @@
+package ssml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// units maps the abbreviations found in the cocktail measures to their
+// singular and plural spoken forms
+var units = map[string][2]string{
+	"oz":     {"ounce", "ounces"},
+	"ounce":  {"ounce", "ounces"},
+	"ounces": {"ounce", "ounces"},
+	"cl":     {"centiliter", "centiliters"},
+	"ml":     {"milliliter", "milliliters"},
+	"tsp":    {"teaspoon", "teaspoons"},
+	"tbsp":   {"tablespoon", "tablespoons"},
+	"tblsp":  {"tablespoon", "tablespoons"},
+	"cup":    {"cup", "cups"},
+	"cups":   {"cup", "cups"},
+	"dash":   {"dash", "dashes"},
+	"dashes": {"dash", "dashes"},
+	"shot":   {"shot", "shots"},
+	"shots":  {"shot", "shots"},
+	"part":   {"part", "parts"},
+	"parts":  {"part", "parts"},
+	"jigger": {"jigger", "jiggers"},
+	"pinch":  {"pinch", "pinches"},
+	"splash": {"splash", "splashes"},
+	"drop":   {"drop", "drops"},
+	"drops":  {"drop", "drops"},
+}
+
+// fraction is a simple fraction, the zero value meaning no fraction
+type fraction struct {
+	num, den int
+}
+
+// Quantity is a parsed cocktail measure
+type Quantity struct {
+	Whole    int
+	Frac     fraction
+	To       int // upper bound of a range such as "2-3", zero otherwise
+	Unit     string
+	Rest     string
+	decimal  string // decimal quantity without a usual fraction such as "0.1"
+	singular string
+	plural   string
+}
+
+// ParseMeasure parses measures such as "1 1/2 oz", "1/2 tsp", "2-3 oz", "2"
+// or "1.5 cl". The boolean is false if the measure doesn't start with a
+// quantity.
+func ParseMeasure(m string) (Quantity, bool) {
+	var q Quantity
+	fs := strings.Fields(m)
+	if len(fs) == 0 {
+		return q, false
+	}
+
+	i := 0
+	switch {
+	case strings.Contains(fs[0], "/"):
+		f, ok := parseFraction(fs[0])
+		if !ok {
+			return q, false
+		}
+		q.Frac = f
+		i = 1
+	case strings.Contains(fs[0], "-"):
+		parts := strings.SplitN(fs[0], "-", 2)
+		a, err1 := strconv.Atoi(parts[0])
+		b, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			return q, false
+		}
+		q.Whole, q.To = a, b
+		i = 1
+	default:
+		if w, err := strconv.Atoi(fs[0]); err == nil {
+			q.Whole = w
+			i = 1
+			if len(fs) > 1 {
+				if f, ok := parseFraction(fs[1]); ok {
+					q.Frac = f
+					i = 2
+				}
+			}
+		} else if d, err := strconv.ParseFloat(fs[0], 64); err == nil {
+			q.Whole = int(d)
+			q.Frac = decimalFraction(d - float64(q.Whole))
+			if q.Frac.den == 0 && d != float64(q.Whole) {
+				q.decimal = strconv.FormatFloat(d, 'f', -1, 64)
+			}
+			i = 1
+		} else {
+			return q, false
+		}
+	}
+
+	if i < len(fs) {
+		u := strings.TrimSuffix(strings.ToLower(fs[i]), ".")
+		if forms, ok := units[u]; ok {
+			q.Unit = u
+			q.singular, q.plural = forms[0], forms[1]
+			i++
+		}
+	}
+	q.Rest = strings.Join(fs[i:], " ")
+	return q, true
+}
+
+func parseFraction(s string) (fraction, bool) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return fraction{}, false
+	}
+	n, err1 := strconv.Atoi(parts[0])
+	d, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || d == 0 || n == 0 {
+		return fraction{}, false
+	}
+	return fraction{num: n, den: d}, true
+}
+
+// fractionEpsilon is the tolerance when matching a decimal part to a fraction
+const fractionEpsilon = 1e-9
+
+// decimalFraction maps the decimal parts that are exactly a quarter, a half or
+// three quarters to their fraction, the other ones having no fraction so that
+// they are spoken as decimals
+func decimalFraction(d float64) fraction {
+	for _, f := range []fraction{{1, 4}, {1, 2}, {3, 4}} {
+		if math.Abs(d-float64(f.num)/float64(f.den)) < fractionEpsilon {
+			return f
+		}
+	}
+	return fraction{}
+}
+
+var small = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+	"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+}
+
+var tens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+// words returns the English words for n, or a say-as element when n is too
+// large to be worth spelling out
+func words(n int) string {
+	switch {
+	case n >= 0 && n < 20:
+		return small[n]
+	case n < 100 && n%10 == 0:
+		return tens[n/10]
+	case n < 100:
+		return tens[n/10] + "-" + small[n%10]
+	}
+	return `<say-as interpret-as="cardinal">` + strconv.Itoa(n) + `</say-as>`
+}
+
+// words returns the fraction in words, the article being part of it as in
+// "a quarter of an ounce"
+func (f fraction) words() string {
+	switch f {
+	case fraction{1, 2}:
+		return "half"
+	case fraction{1, 3}:
+		return "a third"
+	case fraction{2, 3}:
+		return "two thirds"
+	case fraction{1, 4}:
+		return "a quarter"
+	case fraction{3, 4}:
+		return "three quarters"
+	case fraction{1, 8}:
+		return "an eighth"
+	}
+	return `<say-as interpret-as="fraction">` + strconv.Itoa(f.num) + "/" + strconv.Itoa(f.den) + `</say-as>`
+}
+
+func article(word string) string {
+	if strings.IndexByte("aeiou", word[0]) >= 0 {
+		return "an " + word
+	}
+	return "a " + word
+}
+
+// speech returns the quantity read in English words
+func (q Quantity) speech() string {
+	var s string
+	switch {
+	case q.To > 0:
+		s = words(q.Whole) + " to " + words(q.To)
+		if q.Unit != "" {
+			s += " " + q.plural
+		}
+	case q.decimal != "":
+		s = `<say-as interpret-as="cardinal">` + q.decimal + `</say-as>`
+		if q.Unit != "" {
+			s += " " + q.plural
+		}
+	case q.Whole == 0 && q.Frac.den != 0:
+		s = q.Frac.words()
+		if q.Unit != "" {
+			if q.Frac == (fraction{1, 2}) {
+				s += " " + article(q.singular)
+			} else {
+				s += " of " + article(q.singular)
+			}
+		}
+	case q.Frac.den == 0:
+		s = words(q.Whole)
+		if q.Unit != "" {
+			if q.Whole == 1 {
+				s += " " + q.singular
+			} else {
+				s += " " + q.plural
+			}
+		}
+	default:
+		fw := q.Frac.words()
+		if fw == "half" {
+			fw = "a half"
+		}
+		s = words(q.Whole) + " and " + fw
+		if q.Unit != "" {
+			s += " " + q.plural
+		}
+	}
+	if q.Rest != "" {
+		s += " " + sayNumbers(q.Rest)
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package ssml
+
+import "testing"
+
+func TestMeasure(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1 1/2 oz", "one and a half ounces"},
+		{"1/2 oz", "half an ounce"},
+		{"1/4 tsp", "a quarter of a teaspoon"},
+		{"3/4 oz", "three quarters of an ounce"},
+		{"1 oz", "one ounce"},
+		{"2 oz", "two ounces"},
+		{"2-3 oz", "two to three ounces"},
+		{"1.5 cl", "one and a half centiliters"},
+		{"2", "two"},
+		{"0", "zero"},
+		{"0 oz", "zero ounces"},
+		{"0.1 oz", `<say-as interpret-as="cardinal">0.1</say-as> ounces`},
+		{"1.1 oz", `<say-as interpret-as="cardinal">1.1</say-as> ounces`},
+		{"1.6 oz", `<say-as interpret-as="cardinal">1.6</say-as> ounces`},
+		{"2.3", `<say-as interpret-as="cardinal">2.3</say-as>`},
+		{"0.9 oz", `<say-as interpret-as="cardinal">0.9</say-as> ounces`},
+		{"2.25 oz", "two and a quarter ounces"},
+		{"0.75 oz", "three quarters of an ounce"},
+		{"1 2/5 oz", `one and <say-as interpret-as="fraction">2/5</say-as> ounces`},
+		{"120 ml", `<say-as interpret-as="cardinal">120</say-as> milliliters`},
+		{"Juice of 1", `Juice of <say-as interpret-as="cardinal">1</say-as>`},
+		{"2 dashes bitters", "two dashes bitters"},
+		{"Fill with", "Fill with"},
+		{"1/0 oz", `<say-as interpret-as="fraction">1/0</say-as> oz`},
+	}
+	for _, tt := range tests {
+		b := New().Measure(tt.in)
+		if got := b.b.String(); got != tt.want {
+			t.Errorf("Measure(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if err := Validate(b.String()); err != nil {
+			t.Errorf("Measure(%q) isn't valid: %v", tt.in, err)
+		}
+	}
+}
+
+func TestIngredient(t *testing.T) {
+	tests := []struct {
+		measure, name string
+		want          string
+	}{
+		{"1 1/2 oz", "Tequila", "one and a half ounces of Tequila"},
+		{"2", "Mint", "two Mint"},
+		{"", "Salt", "Salt"},
+		{"Juice of 1", "Lime", `Juice of <say-as interpret-as="cardinal">1</say-as> Lime`},
+		{"1 oz", "Rock & Rye", "one ounce of Rock &amp; Rye"},
+	}
+	for _, tt := range tests {
+		if got := New().Ingredient(tt.measure, tt.name).b.String(); got != tt.want {
+			t.Errorf("Ingredient(%q, %q) = %q, want %q", tt.measure, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		in    string
+		valid bool
+	}{
+		{"<speak>Hello</speak>", true},
+		{`<speak><s>One</s><break time="300ms"/><p><s>Two</s></p></speak>`, true},
+		{`<speak><say-as interpret-as="cardinal">2</say-as></speak>`, true},
+		{`<speak><break time="1.5s"/></speak>`, true},
+		{"", false},
+		{"Hello", false},
+		{"<p>Hello</p>", false},
+		{"<speak>One</speak><speak>Two</speak>", false},
+		{"Hello <speak>World</speak>", false},
+		{"<speak><div>Hello</div></speak>", false},
+		{"<speak><say-as>2</say-as></speak>", false},
+		{`<speak><break time="soon"/></speak>`, false},
+		{"<speak><s>Unclosed</speak>", false},
+		{"<speak>Tom & Jerry</speak>", false},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.in)
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%q) = %v, want no error", tt.in, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Validate(%q) = nil, want an error", tt.in)
+		}
+	}
+}
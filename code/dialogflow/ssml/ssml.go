@@ -0,0 +1,116 @@
+package ssml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Builder builds an SSML document for spoken surfaces. Text passed to the
+// builder is escaped, and standalone numbers and fractions are wrapped in a
+// say-as element so that they are read as such.
+type Builder struct {
+	b strings.Builder
+}
+
+// New returns a new empty Builder
+func New() *Builder {
+	return &Builder{}
+}
+
+var number = regexp.MustCompile(`\b\d+(/\d+)?\b`)
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// sayNumbers escapes s and wraps every number or fraction it contains in a
+// say-as element
+func sayNumbers(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range number.FindAllStringIndex(s, -1) {
+		b.WriteString(escape(s[last:loc[0]]))
+		n := s[loc[0]:loc[1]]
+		if strings.Contains(n, "/") {
+			b.WriteString(`<say-as interpret-as="fraction">` + n + `</say-as>`)
+		} else {
+			b.WriteString(`<say-as interpret-as="cardinal">` + n + `</say-as>`)
+		}
+		last = loc[1]
+	}
+	b.WriteString(escape(s[last:]))
+	return b.String()
+}
+
+// Text appends some text
+func (b *Builder) Text(s string) *Builder {
+	b.b.WriteString(sayNumbers(s))
+	return b
+}
+
+// Sentence appends some text as a sentence
+func (b *Builder) Sentence(s string) *Builder {
+	b.b.WriteString("<s>" + sayNumbers(s) + "</s>")
+	return b
+}
+
+// Paragraph appends the sentences as a paragraph
+func (b *Builder) Paragraph(sentences ...string) *Builder {
+	b.b.WriteString("<p>")
+	for _, s := range sentences {
+		b.Sentence(s)
+	}
+	b.b.WriteString("</p>")
+	return b
+}
+
+// Break appends a pause of the given duration
+func (b *Builder) Break(d time.Duration) *Builder {
+	fmt.Fprintf(&b.b, `<break time="%dms"/>`, d.Milliseconds())
+	return b
+}
+
+// SayAs appends a value that should be interpreted in a specific way, for
+// example "cardinal", "ordinal" or "fraction"
+func (b *Builder) SayAs(interpretAs, s string) *Builder {
+	fmt.Fprintf(&b.b, `<say-as interpret-as="%s">%s</say-as>`, escape(interpretAs), escape(s))
+	return b
+}
+
+// Measure appends a cocktail measure such as "1 1/2 oz" read in words, "one
+// and a half ounces". Measures that can't be parsed are appended as text.
+func (b *Builder) Measure(m string) *Builder {
+	if q, ok := ParseMeasure(m); ok {
+		b.b.WriteString(q.speech())
+		return b
+	}
+	return b.Text(m)
+}
+
+// Ingredient appends an ingredient along with its measure, "one and a half
+// ounces of Tequila"
+func (b *Builder) Ingredient(measure, name string) *Builder {
+	q, ok := ParseMeasure(measure)
+	switch {
+	case measure == "":
+		return b.Text(name)
+	case !ok:
+		return b.Text(measure + " " + name)
+	case q.Unit != "":
+		b.b.WriteString(q.speech())
+		return b.Text(" of " + name)
+	default:
+		b.b.WriteString(q.speech())
+		return b.Text(" " + name)
+	}
+}
+
+// String returns the complete SSML document
+func (b *Builder) String() string {
+	return "<speak>" + b.b.String() + "</speak>"
+}
@@ -0,0 +1,86 @@
+package ssml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// elements lists the SSML elements supported by Actions on Google along with
+// their required attribute, if any
+var elements = map[string]string{
+	"speak":    "",
+	"break":    "",
+	"say-as":   "interpret-as",
+	"p":        "",
+	"s":        "",
+	"sub":      "alias",
+	"emphasis": "",
+	"prosody":  "",
+	"audio":    "src",
+}
+
+var breakTime = regexp.MustCompile(`^\d+(\.\d+)?m?s$`)
+
+// Validate checks that s is a well formed SSML document wrapped in a speak
+// element, using only supported elements with their required attributes
+func Validate(s string) error {
+	d := xml.NewDecoder(strings.NewReader(s))
+	depth := 0
+	roots := 0
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			name := e.Name.Local
+			if depth == 0 {
+				roots++
+				if name != "speak" || roots > 1 {
+					return fmt.Errorf("document must have a single speak root element, got %q", name)
+				}
+			}
+			req, ok := elements[name]
+			if !ok {
+				return fmt.Errorf("unsupported element %q", name)
+			}
+			if err = checkAttrs(name, req, e.Attr); err != nil {
+				return err
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && strings.TrimSpace(string(e)) != "" {
+				return fmt.Errorf("text outside of the speak element")
+			}
+		}
+	}
+	if roots == 0 {
+		return fmt.Errorf("missing speak element")
+	}
+	return nil
+}
+
+func checkAttrs(name, required string, attrs []xml.Attr) error {
+	found := required == ""
+	for _, a := range attrs {
+		if a.Name.Local == required {
+			found = true
+		}
+		if name == "break" && a.Name.Local == "time" && !breakTime.MatchString(a.Value) {
+			return fmt.Errorf("invalid break time %q", a.Value)
+		}
+	}
+	if !found {
+		return fmt.Errorf("element %q requires the %q attribute", name, required)
+	}
+	return nil
+}
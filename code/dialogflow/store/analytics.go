@@ -0,0 +1,107 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Outcomes of a webhook call
+const (
+	OutcomeOK         = "ok"
+	OutcomeUnknown    = "unknown"
+	OutcomeInvalid    = "invalid"
+	OutcomeBadRequest = "bad_request"
+	OutcomeError      = "error"
+	OutcomeTimeout    = "timeout"
+)
+
+// failures lists the outcomes counted as failures in the reports
+var failures = []string{OutcomeInvalid, OutcomeBadRequest, OutcomeError, OutcomeTimeout}
+
+// Call is a single webhook call as recorded for analytics
+type Call struct {
+	ID         uint      `gorm:"primary_key"`
+	CreatedAt  time.Time `gorm:"index"`
+	Action     string    `gorm:"index"`
+	Intent     string
+	Parameters string
+	Language   string
+	LatencyMS  int64
+	Outcome    string `gorm:"index"`
+	Confidence float64
+}
+
+// RecordCall saves the call
+func (s *Store) RecordCall(c *Call) error {
+	return s.DB.Create(c).Error
+}
+
+// DailyCount is the number of calls of an action on a given day
+type DailyCount struct {
+	Day    string
+	Action string
+	Count  int
+}
+
+// DailyCounts returns the number of calls per day and action since the given
+// time, most recent day first
+func (s *Store) DailyCounts(since time.Time) ([]DailyCount, error) {
+	var dcs []DailyCount
+	err := s.DB.Model(&Call{}).
+		Select("date(created_at) AS day, action, count(*) AS count").
+		Where("created_at >= ?", since).
+		Group("date(created_at), action").
+		Order("day desc, count desc, action").
+		Scan(&dcs).Error
+	return dcs, err
+}
+
+// ActionCount is the number of calls of an action
+type ActionCount struct {
+	Action string
+	Count  int
+}
+
+// TopUnknownActions returns the actions most often received without a
+// registered handler since the given time
+func (s *Store) TopUnknownActions(since time.Time, limit int) ([]ActionCount, error) {
+	var acs []ActionCount
+	err := s.DB.Model(&Call{}).
+		Select("action, count(*) AS count").
+		Where("created_at >= ? AND outcome = ?", since, OutcomeUnknown).
+		Group("action").
+		Order("count desc, action").
+		Limit(limit).
+		Scan(&acs).Error
+	return acs, err
+}
+
+// FailureRate is the share of failed calls of an action
+type FailureRate struct {
+	Action   string
+	Total    int
+	Failures int
+	Rate     float64
+}
+
+// FailureRates returns the failure rate of every known action since the
+// given time, highest rate first
+func (s *Store) FailureRates(since time.Time) ([]FailureRate, error) {
+	var frs []FailureRate
+	err := s.DB.Model(&Call{}).
+		Select("action, count(*) AS total, sum(CASE WHEN outcome IN (?) THEN 1 ELSE 0 END) AS failures", failures).
+		Where("created_at >= ? AND outcome <> ?", since, OutcomeUnknown).
+		Group("action").
+		Order("action").
+		Scan(&frs).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range frs {
+		if frs[i].Total > 0 {
+			frs[i].Rate = float64(frs[i].Failures) / float64(frs[i].Total)
+		}
+	}
+	sort.SliceStable(frs, func(i, j int) bool { return frs[i].Rate > frs[j].Rate })
+	return frs, nil
+}
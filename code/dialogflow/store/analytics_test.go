@@ -0,0 +1,83 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// seed records calls of the given action and outcome at the given time
+func seed(t *testing.T, s *Store, at time.Time, action, outcome string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := s.RecordCall(&Call{CreatedAt: at, Action: action, Outcome: outcome}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAnalytics(t *testing.T) {
+	s := open(t)
+	day := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	before := day.AddDate(0, 0, -1)
+	old := day.AddDate(0, 0, -10)
+
+	seed(t, s, day, "search", OutcomeOK, 3)
+	seed(t, s, day, "search", OutcomeTimeout, 1)
+	seed(t, s, day, "random", OutcomeOK, 2)
+	seed(t, s, day, "drinks.order", OutcomeUnknown, 2)
+	seed(t, s, before, "random", OutcomeError, 2)
+	seed(t, s, before, "weather", OutcomeUnknown, 3)
+	seed(t, s, before, "recipe.start", OutcomeBadRequest, 1)
+	seed(t, s, before, "recipe.start", OutcomeInvalid, 1)
+	seed(t, s, old, "search", OutcomeError, 5)
+	seed(t, s, old, "jokes", OutcomeUnknown, 10)
+	since := day.AddDate(0, 0, -7)
+
+	t.Run("daily counts", func(t *testing.T) {
+		dcs, err := s.DailyCounts(since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []DailyCount{
+			{"2024-03-02", "search", 4},
+			{"2024-03-02", "drinks.order", 2},
+			{"2024-03-02", "random", 2},
+			{"2024-03-01", "weather", 3},
+			{"2024-03-01", "random", 2},
+			{"2024-03-01", "recipe.start", 2},
+		}
+		if !reflect.DeepEqual(dcs, want) {
+			t.Errorf("got %+v, expected %+v", dcs, want)
+		}
+	})
+
+	t.Run("top unknown actions", func(t *testing.T) {
+		acs, err := s.TopUnknownActions(since, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []ActionCount{{"weather", 3}, {"drinks.order", 2}}
+		if !reflect.DeepEqual(acs, want) {
+			t.Errorf("got %+v, expected %+v", acs, want)
+		}
+		if acs, _ := s.TopUnknownActions(old, 1); !reflect.DeepEqual(acs, []ActionCount{{"jokes", 10}}) {
+			t.Errorf("got %+v, expected jokes only", acs)
+		}
+	})
+
+	t.Run("failure rates", func(t *testing.T) {
+		frs, err := s.FailureRates(since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []FailureRate{
+			{Action: "recipe.start", Total: 2, Failures: 2, Rate: 1},
+			{Action: "random", Total: 4, Failures: 2, Rate: 0.5},
+			{Action: "search", Total: 4, Failures: 1, Rate: 0.25},
+		}
+		if !reflect.DeepEqual(frs, want) {
+			t.Errorf("got %+v, expected %+v", frs, want)
+		}
+	})
+}
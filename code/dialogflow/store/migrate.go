@@ -0,0 +1,61 @@
+package store
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+// Migrate runs the migrations that weren't applied yet
+func Migrate(db *gorm.DB) error {
+	m := gormigrate.New(db, gormigrate.DefaultOptions, []*gormigrate.Migration{
+		{
+			ID: "initial",
+			Migrate: func(tx *gorm.DB) error {
+				type User struct {
+					gorm.Model
+					Identity string `gorm:"unique_index"`
+				}
+
+				type Favorite struct {
+					gorm.Model
+					UserID    uint   `gorm:"unique_index:idx_favorite_user_drink"`
+					DrinkID   string `gorm:"unique_index:idx_favorite_user_drink"`
+					DrinkName string
+				}
+
+				type View struct {
+					gorm.Model
+					UserID    uint `gorm:"index"`
+					DrinkID   string
+					DrinkName string
+				}
+				return tx.CreateTable(&User{}, &Favorite{}, &View{}).Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.DropTable("users", "favorites", "views").Error
+			},
+		}, {
+			ID: "add calls",
+			Migrate: func(tx *gorm.DB) error {
+				type Call struct {
+					ID         uint      `gorm:"primary_key"`
+					CreatedAt  time.Time `gorm:"index"`
+					Action     string    `gorm:"index"`
+					Intent     string
+					Parameters string
+					Language   string
+					LatencyMS  int64
+					Outcome    string `gorm:"index"`
+					Confidence float64
+				}
+				return tx.CreateTable(&Call{}).Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.DropTable("calls").Error
+			},
+		},
+	})
+	return m.Migrate()
+}
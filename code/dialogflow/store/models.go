@@ -0,0 +1,28 @@
+package store
+
+import "github.com/jinzhu/gorm"
+
+// User is an assistant user, identified by its Dialogflow identity which is
+// either the Actions on Google user ID or the session ID
+type User struct {
+	gorm.Model
+	Identity  string `gorm:"unique_index"`
+	Favorites []Favorite
+	Views     []View
+}
+
+// Favorite is a drink saved by a user
+type Favorite struct {
+	gorm.Model
+	UserID    uint   `gorm:"unique_index:idx_favorite_user_drink"`
+	DrinkID   string `gorm:"unique_index:idx_favorite_user_drink"`
+	DrinkName string
+}
+
+// View is a drink that was shown to a user
+type View struct {
+	gorm.Model
+	UserID    uint `gorm:"index"`
+	DrinkID   string
+	DrinkName string
+}
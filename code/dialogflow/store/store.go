@@ -0,0 +1,110 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// HistorySize is the number of views kept for each user
+const HistorySize = 20
+
+// Store gives access to the users, their favorites and their history
+type Store struct {
+	DB *gorm.DB
+}
+
+// Open opens the database, runs the migrations and returns the Store. The
+// gorm dialect has to be imported by the caller.
+func Open(dialect, dsn string) (*Store, error) {
+	db, err := gorm.Open(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == "sqlite3" {
+		// An in memory sqlite database only lives as long as its connection
+		db.DB().SetMaxOpenConns(1)
+	}
+	if err = Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{DB: db}, nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.DB.Close()
+}
+
+// User returns the user with the given identity, creating it if needed
+func (s *Store) User(identity string) (*User, error) {
+	u := &User{}
+	err := s.DB.Where(User{Identity: identity}).FirstOrCreate(u).Error
+	return u, err
+}
+
+// AddFavorite saves the drink in the favorites of the user. The boolean is
+// false if the drink was already saved.
+func (s *Store) AddFavorite(u *User, drinkID, drinkName string) (bool, error) {
+	var count int
+	if err := s.DB.Model(&Favorite{}).Where("user_id = ? AND drink_id = ?", u.ID, drinkID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	err := s.DB.Create(&Favorite{UserID: u.ID, DrinkID: drinkID, DrinkName: drinkName}).Error
+	return err == nil, err
+}
+
+// RemoveFavorite removes the drink from the favorites of the user. The
+// boolean is false if the drink wasn't saved.
+func (s *Store) RemoveFavorite(u *User, drinkID string) (bool, error) {
+	// Hard delete so that the drink can be saved again later on
+	res := s.DB.Unscoped().Where("user_id = ? AND drink_id = ?", u.ID, drinkID).Delete(&Favorite{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Favorites returns the favorites of the user, oldest first
+func (s *Store) Favorites(u *User) ([]Favorite, error) {
+	var fs []Favorite
+	err := s.DB.Where("user_id = ?", u.ID).Order("created_at, id").Find(&fs).Error
+	return fs, err
+}
+
+// FindFavorite returns the first favorite of the user whose drink name
+// contains name, ignoring case, or nil
+func (s *Store) FindFavorite(u *User, name string) (*Favorite, error) {
+	fs, err := s.Favorites(u)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fs {
+		if strings.Contains(strings.ToLower(fs[i].DrinkName), strings.ToLower(name)) {
+			return &fs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// AddView records that the drink was shown to the user and only keeps the
+// last HistorySize views
+func (s *Store) AddView(u *User, drinkID, drinkName string) error {
+	if err := s.DB.Create(&View{UserID: u.ID, DrinkID: drinkID, DrinkName: drinkName}).Error; err != nil {
+		return err
+	}
+	var ids []uint
+	err := s.DB.Model(&View{}).Where("user_id = ?", u.ID).Order("created_at desc, id desc").Pluck("id", &ids).Error
+	if err != nil || len(ids) <= HistorySize {
+		return err
+	}
+	return s.DB.Unscoped().Where("id IN (?)", ids[HistorySize:]).Delete(&View{}).Error
+}
+
+// RecentViews returns the last n drinks shown to the user, most recent first
+func (s *Store) RecentViews(u *User, n int) ([]View, error) {
+	var vs []View
+	err := s.DB.Where("user_id = ?", u.ID).Order("created_at desc, id desc").Limit(n).Find(&vs).Error
+	return vs, err
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	_ "github.com/jinzhu/gorm/dialects/sqlite" // in memory store
+)
+
+// open returns an empty in memory store, closed at the end of the test
+func open(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func user(t *testing.T, s *Store, identity string) *User {
+	t.Helper()
+	u, err := s.User(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestUser(t *testing.T) {
+	s := open(t)
+	a := user(t, s, "ABwppHF")
+	if a.ID == 0 || a.Identity != "ABwppHF" {
+		t.Fatalf("unexpected user %+v", a)
+	}
+	if again := user(t, s, "ABwppHF"); again.ID != a.ID {
+		t.Errorf("got user %d for the same identity, expected %d", again.ID, a.ID)
+	}
+	if other := user(t, s, "projects/p/agent/sessions/s"); other.ID == a.ID {
+		t.Error("got the same user for another identity")
+	}
+	var count int
+	if err := s.DB.Model(&User{}).Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("got %d users (%v), expected 2", count, err)
+	}
+}
+
+func TestFavorites(t *testing.T) {
+	s := open(t)
+	u := user(t, s, "me")
+	other := user(t, s, "other")
+
+	for _, tt := range []struct {
+		u     *User
+		id    string
+		name  string
+		added bool
+	}{
+		{u, "11000", "Mojito", true},
+		{u, "11007", "Margarita", true},
+		{u, "11000", "Mojito", false},
+		{other, "11000", "Mojito", true},
+	} {
+		added, err := s.AddFavorite(tt.u, tt.id, tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != tt.added {
+			t.Errorf("adding %s for %s: got %v, expected %v", tt.name, tt.u.Identity, added, tt.added)
+		}
+	}
+
+	fs, err := s.Favorites(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 2 || fs[0].DrinkName != "Mojito" || fs[1].DrinkName != "Margarita" {
+		t.Errorf("unexpected favorites %+v", fs)
+	}
+	f, err := s.FindFavorite(u, "marga")
+	if err != nil || f == nil || f.DrinkID != "11007" {
+		t.Errorf("got %+v (%v), expected Margarita", f, err)
+	}
+	if f, err := s.FindFavorite(u, "daiquiri"); err != nil || f != nil {
+		t.Errorf("got %+v (%v), expected nothing", f, err)
+	}
+
+	// Removed favorites can be saved again and only the user's one is removed
+	removed, err := s.RemoveFavorite(u, "11000")
+	if err != nil || !removed {
+		t.Fatalf("got %v (%v), expected the favorite to be removed", removed, err)
+	}
+	if removed, err := s.RemoveFavorite(u, "11000"); err != nil || removed {
+		t.Errorf("got %v (%v), expected nothing to remove", removed, err)
+	}
+	if fs, _ := s.Favorites(other); len(fs) != 1 {
+		t.Errorf("got %d favorites for the other user, expected 1", len(fs))
+	}
+	if added, err := s.AddFavorite(u, "11000", "Mojito"); err != nil || !added {
+		t.Errorf("got %v (%v), expected the favorite to be saved again", added, err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	s := open(t)
+	u := user(t, s, "me")
+	other := user(t, s, "other")
+	if err := s.AddView(other, "11000", "Mojito"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < HistorySize+5; i++ {
+		if err := s.AddView(u, fmt.Sprint(i), fmt.Sprintf("Drink %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vs, err := s.RecentViews(u, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 3 || vs[0].DrinkName != "Drink 24" || vs[2].DrinkName != "Drink 22" {
+		t.Errorf("unexpected recent views %+v", vs)
+	}
+
+	var count int
+	if err := s.DB.Unscoped().Model(&View{}).Where("user_id = ?", u.ID).Count(&count).Error; err != nil || count != HistorySize {
+		t.Errorf("got %d views (%v), expected %d", count, err, HistorySize)
+	}
+	if vs, _ := s.RecentViews(u, HistorySize+5); len(vs) != HistorySize || vs[HistorySize-1].DrinkName != "Drink 5" {
+		t.Errorf("got %d views, expected the last %d", len(vs), HistorySize)
+	}
+	if vs, _ := s.RecentViews(other, 10); len(vs) != 1 {
+		t.Errorf("got %d views for the other user, expected 1", len(vs))
+	}
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+)
+
+func cardFromDrink(d *cocktail.FullDrink) *fulfillment.BasicCard {
+	card := &fulfillment.BasicCard{
+		Title:         d.StrDrink,
+		FormattedText: d.StrInstructions,
+		Image: &fulfillment.Image{
+			URI: d.StrDrinkThumb,
+		},
+	}
+	return card
+}
+
+type searchParams struct {
+	Alcohol    string `json:"alcohol"`
+	DrinkType  string `json:"drink-type"`
+	Ingredient string `json:"ingredient"`
+	Glass      string `json:"glass"`
+	Name       string `json:"name"`
+}
+
+// merge overrides the parameters of p with the non-empty ones of o
+func (p searchParams) merge(o searchParams) searchParams {
+	for _, f := range []struct{ dst, src *string }{
+		{&p.Alcohol, &o.Alcohol},
+		{&p.DrinkType, &o.DrinkType},
+		{&p.Ingredient, &o.Ingredient},
+		{&p.Glass, &o.Glass},
+		{&p.Name, &o.Name},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	return p
+}
+
+// matches returns true if the drink satisfies every non-empty parameter, the
+// alcohol being looked up in the ingredients like any other ingredient
+func (p searchParams) matches(d *cocktail.FullDrink) bool {
+	has := func(want string) bool {
+		for _, i := range d.Ingredients() {
+			if strings.Contains(strings.ToLower(i.Name), strings.ToLower(want)) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case p.Alcohol != "" && !has(p.Alcohol):
+		return false
+	case p.Ingredient != "" && !has(p.Ingredient):
+		return false
+	case p.DrinkType != "" && !strings.EqualFold(d.StrCategory, p.DrinkType):
+		return false
+	case p.Glass != "" && !strings.EqualFold(d.StrGlass, p.Glass):
+		return false
+	}
+	return true
+}
+
+func (w *Webhook) search(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	var err error
+	var p searchParams
+
+	if err = dfr.GetParams(&p); err != nil {
+		return nil, badRequest(err, "Couldn't get parameters")
+	}
+	return w.searchDrinks(ctx, dfr, p)
+}
+
+// specify narrows the previous search with the parameters given by the user
+func (w *Webhook) specify(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	var err error
+	var p, np searchParams
+
+	if err = dfr.GetContext(searchContext, &p); err != nil {
+		return nil, badRequest(err, "Couldn't get parameters")
+	}
+	if err = dfr.GetParams(&np); err != nil {
+		return nil, badRequest(err, "Couldn't get parameters")
+	}
+	return w.searchDrinks(ctx, dfr, p.merge(np))
+}
+
+// searchDrinks answers with the first drink matching the parameters, which are
+// kept in the search context so that the user can narrow the search
+func (w *Webhook) searchDrinks(ctx context.Context, dfr *fulfillment.Request, p searchParams) (*fulfillment.Response, error) {
+	var err error
+	var ds []*cocktail.FullDrink
+
+	if ds, err = w.Source.SearchDrinks(ctx, p.Name); err != nil {
+		return nil, fmt.Errorf("couldn't search drinks: %v", err)
+	}
+	var found []*cocktail.FullDrink
+	for _, d := range ds {
+		if p.matches(d) {
+			found = append(found, d)
+		}
+	}
+	l := w.localizer(dfr)
+	if len(found) == 0 {
+		return fulfillment.TextResponse(l.T("search.none", nil)), nil
+	}
+
+	d := found[0]
+	w.recordView(dfr, d)
+	out := l.Plural("search.found", len(found), i18n.Vars{"name": d.StrDrink})
+	dff, err := w.drinkResponse(dfr, out, d)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := dfr.NewContext(searchContext, contextLifespan, p)
+	if err != nil {
+		return nil, err
+	}
+	dff.OutputContexts = append(dff.OutputContexts, sc)
+	return dff, nil
+}
+
+// drinkResponse shows the drink along with the given text and remembers it in
+// the drink context
+func (w *Webhook) drinkResponse(dfr *fulfillment.Request, out string, d *cocktail.FullDrink) (*fulfillment.Response, error) {
+	speech := []fulfillment.SimpleResponse{
+		{SSML: speechFromDrink(w.localizer(dfr), out, d), DisplayText: out},
+	}
+	dc, err := dfr.NewContext(drinkContext, contextLifespan, drinkParams{ID: d.IDDrink, Name: d.StrDrink})
+	if err != nil {
+		return nil, err
+	}
+	dff := &fulfillment.Response{
+		Messages: []fulfillment.Message{
+			{Text: []string{out}},
+			{Platform: fulfillment.ActionsOnGoogle, SimpleResponses: speech},
+			{Platform: fulfillment.ActionsOnGoogle, BasicCard: cardFromDrink(d)},
+		},
+		OutputContexts: []fulfillment.Context{dc},
+	}
+	return dff, nil
+}
+
+func (w *Webhook) random(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	var err error
+	var d *cocktail.FullDrink
+
+	if d, err = w.Source.GetRandomDrink(ctx); err != nil {
+		return nil, fmt.Errorf("couldn't get random drink: %v", err)
+	}
+
+	w.recordView(dfr, d)
+
+	out := w.localizer(dfr).T("random.found", i18n.Vars{"name": d.StrDrink})
+	return w.drinkResponse(dfr, out, d)
+}
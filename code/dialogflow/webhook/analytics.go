@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/store"
+)
+
+// record saves the call in the store for analytics. The request is nil when
+// it couldn't be decoded.
+func (w *Webhook) record(dfr *fulfillment.Request, start time.Time, outcome string) {
+	if w.Options.Store == nil {
+		return
+	}
+	c := &store.Call{
+		LatencyMS: time.Since(start).Milliseconds(),
+		Outcome:   outcome,
+	}
+	if dfr != nil {
+		c.Action = dfr.Action
+		c.Intent = dfr.Intent
+		c.Language = dfr.Language
+		c.Confidence = dfr.Confidence
+		if b, err := json.Marshal(dfr.Parameters); err == nil {
+			c.Parameters = string(b)
+		}
+	}
+	if err := w.Options.Store.RecordCall(c); err != nil {
+		logrus.WithError(err).Warn("Couldn't record call")
+	}
+}
@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+)
+
+const (
+	// DefaultDeadline leaves some room before Dialogflow gives up on the
+	// webhook, which happens after about 5 seconds
+	DefaultDeadline = 4 * time.Second
+	// lateTTL is how long a late result is kept waiting for the next turn
+	lateTTL = 2 * time.Minute
+	// lateTimeout bounds actions whose result is cached, since nobody waits
+	// for them anymore
+	lateTimeout = 30 * time.Second
+)
+
+// call is a single execution of an action which may outlive the request that
+// started it
+type call struct {
+	done chan struct{}
+	dff  *fulfillment.Response
+	err  error
+	at   time.Time
+}
+
+// lateCache holds the calls that missed their deadline, keyed by session,
+// action and parameters, see lateKey
+type lateCache struct {
+	sync.Mutex
+	calls map[string]*call
+}
+
+func newLateCache() *lateCache {
+	return &lateCache{calls: make(map[string]*call)}
+}
+
+// take removes and returns the call stored for key, if any
+func (l *lateCache) take(key string) *call {
+	l.Lock()
+	defer l.Unlock()
+
+	cl, ok := l.calls[key]
+	if !ok {
+		return nil
+	}
+	delete(l.calls, key)
+	if time.Since(cl.at) > lateTTL {
+		return nil
+	}
+	return cl
+}
+
+// put stores the call for key and evicts the expired ones
+func (l *lateCache) put(key string, cl *call) {
+	l.Lock()
+	defer l.Unlock()
+
+	for k, c := range l.calls {
+		if time.Since(c.at) > lateTTL {
+			delete(l.calls, k)
+		}
+	}
+	l.calls[key] = cl
+}
+
+// lateKey returns the key of the late cache for the request, so that a late
+// result is only served to the same action asked with the same parameters
+func lateKey(name string, dfr *fulfillment.Request) string {
+	h := fnv.New64a()
+	// The map keys are sorted by encoding/json, which makes the hash stable
+	b, _ := json.Marshal(dfr.Parameters)
+	h.Write(b)
+	return dfr.Session + "|" + name + "|" + strconv.FormatUint(h.Sum64(), 16)
+}
+
+// deadline returns the deadline that applies to the given action
+func (w *Webhook) deadline(name string) time.Duration {
+	if d, ok := w.Options.Deadlines[name]; ok && d > 0 {
+		return d
+	}
+	if w.Options.Deadline > 0 {
+		return w.Options.Deadline
+	}
+	return DefaultDeadline
+}
+
+// run executes the action under its deadline. When the deadline is exceeded a
+// fallback response is returned along with true and, if enabled, the call is
+// kept running so that its result can be sent on the next turn.
+func (w *Webhook) run(name string, a fulfillment.Handler, dfr *fulfillment.Request) (*fulfillment.Response, bool, error) {
+	d := w.deadline(name)
+	key := lateKey(name, dfr)
+	actionCalls.Add(name, 1)
+
+	var cl *call
+	if w.Options.CacheLate {
+		if cl = w.late.take(key); cl != nil {
+			actionLateServed.Add(name, 1)
+		}
+	}
+	if cl == nil {
+		cl = &call{done: make(chan struct{}), at: time.Now()}
+		timeout := d
+		if w.Options.CacheLate {
+			timeout = lateTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		go func() {
+			defer cancel()
+			cl.dff, cl.err = a.Fulfill(ctx, dfr)
+			close(cl.done)
+		}()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-cl.done:
+		if cl.err != nil {
+			actionErrors.Add(name, 1)
+		}
+		return cl.dff, false, cl.err
+	case <-t.C:
+	}
+
+	actionTimeouts.Add(name, 1)
+	logrus.WithFields(logrus.Fields{"action": name, "deadline": d}).Warn("Deadline exceeded")
+	if w.Options.CacheLate {
+		w.late.put(key, cl)
+	}
+	return fulfillment.TextResponse(w.localizer(dfr).T("deadline.still_looking", nil)), true, nil
+}
@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/jinzhu/gorm/dialects/sqlite" // in memory store
+
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+	"github.com/Depado/articles/code/dialogflow/store"
+)
+
+func TestLateKey(t *testing.T) {
+	req := func(params map[string]interface{}) *fulfillment.Request {
+		return &fulfillment.Request{Session: "projects/p/agent/sessions/s", Parameters: params}
+	}
+	a := lateKey("search", req(map[string]interface{}{"alcohol": "rum", "glass": ""}))
+	if b := lateKey("search", req(map[string]interface{}{"glass": "", "alcohol": "rum"})); a != b {
+		t.Errorf("same parameters gave different keys %q and %q", a, b)
+	}
+	if b := lateKey("search", req(map[string]interface{}{"alcohol": "gin", "glass": ""})); a == b {
+		t.Errorf("different parameters gave the same key %q", a)
+	}
+	if b := lateKey("random", req(map[string]interface{}{"alcohol": "rum", "glass": ""})); a == b {
+		t.Errorf("different actions gave the same key %q", a)
+	}
+}
+
+// blocking returns an action answering text once release is closed, counting
+// its calls
+func blocking(release <-chan struct{}, calls *int32, text string) Action {
+	return func(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+		atomic.AddInt32(calls, 1)
+		select {
+		case <-release:
+			return fulfillment.TextResponse(text), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func newTestWebhook(t *testing.T, o Options) *Webhook {
+	c, err := i18n.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Catalog = c
+	return New(nil, o)
+}
+
+func searchRequest(session, alcohol string) *fulfillment.Request {
+	return &fulfillment.Request{
+		Session:    "projects/p/agent/sessions/" + session,
+		Action:     "search",
+		Language:   "en",
+		Parameters: map[string]interface{}{"alcohol": alcohol},
+	}
+}
+
+func TestRunDeadline(t *testing.T) {
+	w := newTestWebhook(t, Options{Deadline: 20 * time.Millisecond})
+	release := make(chan struct{})
+	defer close(release)
+	var calls int32
+
+	dff, timedOut, err := w.run("search", blocking(release, &calls, "done"), searchRequest("s", "rum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timedOut {
+		t.Error("expected the action to time out")
+	}
+	if want := "I'm still looking, ask me again in a moment."; dff.Text != want {
+		t.Errorf("got %q, expected %q", dff.Text, want)
+	}
+}
+
+func TestRunCacheLate(t *testing.T) {
+	w := newTestWebhook(t, Options{Deadline: 50 * time.Millisecond, CacheLate: true})
+	release := make(chan struct{})
+	var calls int32
+	a := blocking(release, &calls, "done")
+
+	if _, timedOut, err := w.run("search", a, searchRequest("s", "rum")); err != nil || !timedOut {
+		t.Fatalf("got timed out %v and error %v, expected a time out", timedOut, err)
+	}
+	close(release)
+
+	// The late result is served on the next turn of the same session, action
+	// and parameters without calling the action again
+	dff, timedOut, err := w.run("search", a, searchRequest("s", "rum"))
+	if err != nil || timedOut {
+		t.Fatalf("got timed out %v and error %v, expected the late result", timedOut, err)
+	}
+	if dff.Text != "done" || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("got %q after %d calls, expected the late result after 1 call", dff.Text, calls)
+	}
+
+	// It's only served once
+	if _, _, err := w.run("search", a, searchRequest("s", "rum")); err != nil || atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("got %d calls and error %v, expected the action to be called again", calls, err)
+	}
+
+	// Other parameters, sessions and actions don't get it
+	release2 := make(chan struct{})
+	var calls2 int32
+	b := blocking(release2, &calls2, "late")
+	if _, timedOut, _ := w.run("search", b, searchRequest("s", "rum")); !timedOut {
+		t.Fatal("expected the action to time out")
+	}
+	close(release2)
+	for _, tt := range []struct {
+		name   string
+		action string
+		dfr    *fulfillment.Request
+	}{
+		{"parameters", "search", searchRequest("s", "gin")},
+		{"session", "search", searchRequest("other", "rum")},
+		{"action", "search.specify", searchRequest("s", "rum")},
+	} {
+		before := atomic.LoadInt32(&calls2)
+		if _, _, err := w.run(tt.action, b, tt.dfr); err != nil {
+			t.Fatal(err)
+		}
+		if atomic.LoadInt32(&calls2) != before+1 {
+			t.Errorf("%s: late result served", tt.name)
+		}
+	}
+}
+
+func TestRunDeadlines(t *testing.T) {
+	w := newTestWebhook(t, Options{
+		Deadline:  10 * time.Millisecond,
+		Deadlines: map[string]time.Duration{"random": time.Second, "search": 0},
+	})
+	tests := []struct {
+		action   string
+		deadline time.Duration
+		timedOut bool
+	}{
+		{"random", time.Second, false},
+		{"search", 10 * time.Millisecond, true},
+		{"recipe.start", 10 * time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			if d := w.deadline(tt.action); d != tt.deadline {
+				t.Errorf("got deadline %s, expected %s", d, tt.deadline)
+			}
+			slow := Action(func(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+				time.Sleep(100 * time.Millisecond)
+				return fulfillment.TextResponse("done"), nil
+			})
+			if _, timedOut, err := w.run(tt.action, slow, searchRequest("s", "rum")); err != nil || timedOut != tt.timedOut {
+				t.Errorf("got timed out %v and error %v, expected timed out %v", timedOut, err, tt.timedOut)
+			}
+		})
+	}
+
+	if d := (&Webhook{}).deadline("search"); d != DefaultDeadline {
+		t.Errorf("got deadline %s, expected %s by default", d, DefaultDeadline)
+	}
+}
+
+func TestHandleTimeout(t *testing.T) {
+	st, err := store.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	w := newTestWebhook(t, Options{Deadline: 20 * time.Millisecond, Store: st})
+	release := make(chan struct{})
+	defer close(release)
+	var calls int32
+	w.actions["search"] = blocking(release, &calls, "done")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	w.Register(r)
+	body := `{"session": "projects/p/agent/sessions/s", "queryResult": {"action": "search", "parameters": {"alcohol": "rum"}, "languageCode": "en"}}`
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, expected 200", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "I'm still looking") {
+		t.Errorf("fallback text not found in %s", rec.Body)
+	}
+
+	var c store.Call
+	if err := st.DB.First(&c).Error; err != nil {
+		t.Fatal(err)
+	}
+	if c.Action != "search" || c.Outcome != store.OutcomeTimeout {
+		t.Errorf("recorded %s with outcome %s, expected search with %s", c.Action, c.Outcome, store.OutcomeTimeout)
+	}
+}
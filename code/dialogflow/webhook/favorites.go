@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+	"github.com/Depado/articles/code/dialogflow/store"
+)
+
+// historyLength is the number of drinks listed by the history action
+const historyLength = 5
+
+// identity returns the identity of the user: the Actions on Google user ID
+// when it is available, the session ID otherwise
+func identity(dfr *fulfillment.Request) string {
+	if u, ok := dfr.Payload["user"].(map[string]interface{}); ok {
+		if id, _ := u["userId"].(string); id != "" {
+			return "google:" + id
+		}
+	}
+	return "session:" + path.Base(dfr.Session)
+}
+
+// user returns the store user matching the request identity
+func (w *Webhook) user(dfr *fulfillment.Request) (*store.User, error) {
+	u, err := w.Options.Store.User(identity(dfr))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get user: %v", err)
+	}
+	return u, nil
+}
+
+// recordView adds the drink to the history of the user, errors are only logged
+// since the history isn't critical
+func (w *Webhook) recordView(dfr *fulfillment.Request, d *cocktail.FullDrink) {
+	if w.Options.Store == nil {
+		return
+	}
+	u, err := w.user(dfr)
+	if err == nil {
+		err = w.Options.Store.AddView(u, d.IDDrink, d.StrDrink)
+	}
+	if err != nil {
+		logrus.WithError(err).Warn("Couldn't record view")
+	}
+}
+
+// withStore answers with an apology when no store is configured
+func (w *Webhook) withStore(a Action) Action {
+	return func(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+		if w.Options.Store == nil {
+			return fulfillment.TextResponse(w.localizer(dfr).T("favorites.unavailable", nil)), nil
+		}
+		return a(ctx, dfr)
+	}
+}
+
+func (w *Webhook) favoritesAdd(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	d, dff, err := w.resolveDrink(ctx, dfr, "favorites.no_drink")
+	if d == nil || err != nil {
+		return dff, err
+	}
+	u, err := w.user(dfr)
+	if err != nil {
+		return nil, err
+	}
+	added, err := w.Options.Store.AddFavorite(u, d.IDDrink, d.StrDrink)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't add favorite: %v", err)
+	}
+	key := "favorites.added"
+	if !added {
+		key = "favorites.already"
+	}
+	return fulfillment.TextResponse(w.localizer(dfr).T(key, i18n.Vars{"name": d.StrDrink})), nil
+}
+
+func (w *Webhook) favoritesRemove(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	var err error
+	var p searchParams
+	var dp drinkParams
+
+	l := w.localizer(dfr)
+	if err = dfr.GetParams(&p); err != nil {
+		return nil, badRequest(err, "Couldn't get parameters")
+	}
+	u, err := w.user(dfr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Favorites are matched by name locally, no need to query the source
+	if p.Name != "" {
+		f, err := w.Options.Store.FindFavorite(u, p.Name)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find favorite: %v", err)
+		}
+		if f == nil {
+			return fulfillment.TextResponse(l.T("favorites.not_saved", i18n.Vars{"name": p.Name})), nil
+		}
+		dp = drinkParams{ID: f.DrinkID, Name: f.DrinkName}
+	} else if err = dfr.GetContext(drinkContext, &dp); err != nil || dp.ID == "" {
+		return fulfillment.TextResponse(l.T("favorites.no_drink", nil)), nil
+	}
+
+	removed, err := w.Options.Store.RemoveFavorite(u, dp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't remove favorite: %v", err)
+	}
+	key := "favorites.removed"
+	if !removed {
+		key = "favorites.not_saved"
+	}
+	return fulfillment.TextResponse(l.T(key, i18n.Vars{"name": dp.Name})), nil
+}
+
+func (w *Webhook) favoritesList(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	u, err := w.user(dfr)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := w.Options.Store.Favorites(u)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list favorites: %v", err)
+	}
+	names := make([]string, 0, len(fs))
+	for _, f := range fs {
+		names = append(names, f.DrinkName)
+	}
+	out := w.localizer(dfr).Plural("favorites.list", len(names), i18n.Vars{"list": strings.Join(names, ", ")})
+	return fulfillment.TextResponse(out), nil
+}
+
+func (w *Webhook) historyList(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	u, err := w.user(dfr)
+	if err != nil {
+		return nil, err
+	}
+	vs, err := w.Options.Store.RecentViews(u, historyLength)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list history: %v", err)
+	}
+	names := make([]string, 0, len(vs))
+	for _, v := range vs {
+		names = append(names, v.DrinkName)
+	}
+	out := w.localizer(dfr).Plural("history.list", len(names), i18n.Vars{"list": strings.Join(names, ", ")})
+	return fulfillment.TextResponse(out), nil
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// pingTimeout is the maximum time the readiness probe waits for the source
+const pingTimeout = 2 * time.Second
+
+// Healthz always answers OK as long as the process is able to serve requests
+func (w *Webhook) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz answers OK only when the cocktail source is reachable and the server
+// isn't shutting down
+func (w *Webhook) Readyz(c *gin.Context) {
+	if atomic.LoadInt32(&w.draining) == 1 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), pingTimeout)
+	defer cancel()
+	if err := w.Source.Ping(ctx); err != nil {
+		logrus.WithError(err).Warn("Cocktail source isn't ready")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Drain marks the webhook as shutting down so that the readiness probe fails
+// and no new traffic is routed to this instance, then waits for the grace
+// period so that the load balancer has time to notice before the server stops
+// accepting connections
+func (w *Webhook) Drain(grace time.Duration) {
+	atomic.StoreInt32(&w.draining, 1)
+	time.Sleep(grace)
+}
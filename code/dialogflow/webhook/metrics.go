@@ -0,0 +1,11 @@
+package webhook
+
+import "expvar"
+
+// Per action counters, exposed on /debug/vars
+var (
+	actionCalls      = expvar.NewMap("webhook_action_calls")
+	actionErrors     = expvar.NewMap("webhook_action_errors")
+	actionTimeouts   = expvar.NewMap("webhook_action_timeouts")
+	actionLateServed = expvar.NewMap("webhook_action_late_served")
+)
@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+	"github.com/Depado/articles/code/dialogflow/ssml"
+)
+
+const (
+	// searchContext is set by the search intent and holds its parameters,
+	// Dialogflow sends the context names in lower case
+	searchContext = "search-followup"
+	// drinkContext remembers the last drink shown to the user
+	drinkContext = "drink"
+	// recipeContext tracks the progress of the user in the guided recipe
+	recipeContext = "recipe"
+	// contextLifespan is the number of turns the contexts are kept
+	contextLifespan = 5
+)
+
+// drinkParams are the parameters of the drink context
+type drinkParams struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// recipeParams are the parameters of the recipe context. Step 0 is the list
+// of ingredients and the following ones are the instruction sentences.
+// Dialogflow may send numbers back as floats, hence the type of Step.
+type recipeParams struct {
+	Drink string  `json:"drink"`
+	Step  float64 `json:"step"`
+}
+
+// resolveDrink returns the drink named in the parameters or, if none is
+// given, the last drink shown to the user. When there is no such drink, a
+// fulfillment explaining why is returned instead, using the noDrink message
+// when the user didn't name any drink.
+func (w *Webhook) resolveDrink(ctx context.Context, dfr *fulfillment.Request, noDrink string) (*cocktail.FullDrink, *fulfillment.Response, error) {
+	var err error
+	var p searchParams
+	var d *cocktail.FullDrink
+
+	l := w.localizer(dfr)
+	if err = dfr.GetParams(&p); err != nil {
+		return nil, nil, badRequest(err, "Couldn't get parameters")
+	}
+
+	if p.Name != "" {
+		var ds []*cocktail.FullDrink
+		if ds, err = w.Source.SearchDrinks(ctx, p.Name); err != nil {
+			return nil, nil, fmt.Errorf("couldn't search drinks: %v", err)
+		}
+		if len(ds) == 0 {
+			return nil, fulfillment.TextResponse(l.T("drink.not_found", i18n.Vars{"name": p.Name})), nil
+		}
+		return ds[0], nil, nil
+	}
+
+	var dp drinkParams
+	if err = dfr.GetContext(drinkContext, &dp); err != nil || dp.ID == "" {
+		return nil, fulfillment.TextResponse(l.T(noDrink, nil)), nil
+	}
+	if d, err = w.Source.LookupDrink(ctx, dp.ID); err != nil {
+		return nil, nil, fmt.Errorf("couldn't lookup drink: %v", err)
+	}
+	return d, nil, nil
+}
+
+// recipeStart starts the guided recipe of the drink given as parameter or, if
+// none is given, of the last drink shown to the user
+func (w *Webhook) recipeStart(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	d, dff, err := w.resolveDrink(ctx, dfr, "recipe.no_drink")
+	if d == nil || err != nil {
+		return dff, err
+	}
+	w.recordView(dfr, d)
+	return w.recipeStep(dfr, d, 0)
+}
+
+// recipeMove moves the guided recipe by delta steps, zero repeating the
+// current one
+func (w *Webhook) recipeMove(delta int) Action {
+	return func(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+		d, step, err := w.recipeProgress(ctx, dfr)
+		if d == nil || err != nil {
+			return fulfillment.TextResponse(w.localizer(dfr).T("recipe.no_drink", nil)), err
+		}
+		return w.recipeStep(dfr, d, step+delta)
+	}
+}
+
+// recipeRestart goes back to the list of ingredients
+func (w *Webhook) recipeRestart(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	d, _, err := w.recipeProgress(ctx, dfr)
+	if d == nil || err != nil {
+		return fulfillment.TextResponse(w.localizer(dfr).T("recipe.no_drink", nil)), err
+	}
+	return w.recipeStep(dfr, d, 0)
+}
+
+// recipeProgress returns the drink and the step stored in the recipe context.
+// The drink is nil if there is no recipe in progress.
+func (w *Webhook) recipeProgress(ctx context.Context, dfr *fulfillment.Request) (*cocktail.FullDrink, int, error) {
+	var p recipeParams
+	if err := dfr.GetContext(recipeContext, &p); err != nil || p.Drink == "" {
+		return nil, 0, nil
+	}
+	d, err := w.Source.LookupDrink(ctx, p.Drink)
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't lookup drink: %v", err)
+	}
+	return d, int(p.Step), nil
+}
+
+// recipeStep answers with the given step of the drink recipe and stores the
+// progress in the recipe context
+func (w *Webhook) recipeStep(dfr *fulfillment.Request, d *cocktail.FullDrink, step int) (*fulfillment.Response, error) {
+	l := w.localizer(dfr)
+	steps := d.Steps()
+	if step < 0 {
+		step = 0
+	}
+	if step > len(steps)+1 {
+		step = len(steps) + 1
+	}
+
+	var out string
+	b := ssml.New()
+	switch {
+	case step == 0:
+		is := d.Ingredients()
+		names := make([]string, 0, len(is))
+		for _, i := range is {
+			names = append(names, strings.TrimSpace(i.Measure+" "+i.Name))
+		}
+		intro := l.Plural("recipe.ingredients", len(is), i18n.Vars{"name": d.StrDrink})
+		out = intro + " " + strings.Join(names, ", ")
+		b.Sentence(intro)
+		for _, i := range is {
+			if l.Lang() == "en" {
+				b.Ingredient(i.Measure, i.Name)
+			} else {
+				b.Text(strings.TrimSpace(i.Measure + " " + i.Name))
+			}
+			b.Break(shortPause)
+		}
+	case step <= len(steps):
+		out = l.T("recipe.step", i18n.Vars{"step": step, "total": len(steps), "text": steps[step-1]})
+		b.Sentence(out)
+	default:
+		out = l.T("recipe.done", i18n.Vars{"name": d.StrDrink})
+		b.Sentence(out)
+	}
+
+	rc, err := dfr.NewContext(recipeContext, contextLifespan, recipeParams{Drink: d.IDDrink, Step: float64(step)})
+	if err != nil {
+		return nil, err
+	}
+	suggestions := []string{
+		l.T("suggestion.next", nil),
+		l.T("suggestion.repeat", nil),
+		l.T("suggestion.previous", nil),
+		l.T("suggestion.start_over", nil),
+	}
+	return &fulfillment.Response{
+		Text: out,
+		Messages: []fulfillment.Message{
+			{Text: []string{out}},
+			{Platform: fulfillment.ActionsOnGoogle, SimpleResponses: []fulfillment.SimpleResponse{
+				{SSML: b.String(), DisplayText: out},
+			}},
+			{Platform: fulfillment.ActionsOnGoogle, Suggestions: suggestions},
+		},
+		OutputContexts: []fulfillment.Context{rc},
+	}, nil
+}
@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+	"github.com/Depado/articles/code/dialogflow/ssml"
+)
+
+// Pauses inserted in the spoken recipe
+const (
+	shortPause = 300 * time.Millisecond
+	stepPause  = 800 * time.Millisecond
+)
+
+// speechFromDrink returns the SSML reading of the drink: the intro, the list
+// of ingredients and then the instructions, one step at a time. Measures are
+// only spelled out in English.
+func speechFromDrink(l *i18n.Localizer, intro string, d *cocktail.FullDrink) string {
+	is := d.Ingredients()
+	b := ssml.New().Sentence(intro).Break(shortPause)
+
+	b.Sentence(l.Plural("speech.ingredients", len(is), nil))
+	for _, i := range is {
+		if l.Lang() == "en" {
+			b.Ingredient(i.Measure, i.Name)
+		} else {
+			b.Text(strings.TrimSpace(i.Measure + " " + i.Name))
+		}
+		b.Break(shortPause)
+	}
+
+	for _, s := range d.Steps() {
+		b.Break(stepPause).Sentence(s)
+	}
+	return b.String()
+}
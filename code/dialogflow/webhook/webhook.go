@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Depado/articles/code/dialogflow/cocktail"
+	"github.com/Depado/articles/code/dialogflow/fulfillment"
+	"github.com/Depado/articles/code/dialogflow/i18n"
+	"github.com/Depado/articles/code/dialogflow/store"
+)
+
+// Action is a function answering a single Dialogflow action
+type Action func(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error)
+
+// Fulfill calls the action, which makes it a fulfillment.Handler
+func (a Action) Fulfill(ctx context.Context, dfr *fulfillment.Request) (*fulfillment.Response, error) {
+	return a(ctx, dfr)
+}
+
+// Options controls how the actions are run
+type Options struct {
+	// Deadline is the maximum time an action can take before a fallback
+	// response is sent back to Dialogflow, DefaultDeadline if zero
+	Deadline time.Duration
+	// Deadlines overrides Deadline for specific actions
+	Deadlines map[string]time.Duration
+	// CacheLate keeps the result of actions that missed their deadline so that
+	// it can be sent on the next turn of the same session
+	CacheLate bool
+	// Catalog holds the localized messages, see i18n.Default
+	Catalog *i18n.Catalog
+	// Store keeps the favorites and history of the users, these features are
+	// disabled if nil
+	Store *store.Store
+	// Codec decodes the Dialogflow ES requests and encodes the responses, the
+	// leboncoin one if nil. Dialogflow CX requests always use fulfillment.CX.
+	Codec fulfillment.Codec
+	// Transitions maps actions to the full name of the page or flow a
+	// Dialogflow CX session transitions to once the action succeeds
+	Transitions map[string]string
+}
+
+// Webhook holds the dependencies needed to answer Dialogflow requests
+type Webhook struct {
+	Source  cocktail.Source
+	Options Options
+
+	actions  map[string]fulfillment.Handler
+	codec    fulfillment.Codec
+	cx       fulfillment.Codec
+	late     *lateCache
+	draining int32
+}
+
+// New returns a new Webhook that will fetch its drinks from the given source
+func New(s cocktail.Source, o Options) *Webhook {
+	w := &Webhook{
+		Source:  s,
+		Options: o,
+		codec:   o.Codec,
+		cx:      fulfillment.CX{},
+		late:    newLateCache(),
+	}
+	if w.codec == nil {
+		w.codec = fulfillment.Leboncoin{}
+	}
+	w.actions = map[string]fulfillment.Handler{
+		"search":           Action(w.search),
+		"search.specify":   Action(w.specify),
+		"random":           Action(w.random),
+		"recipe.start":     Action(w.recipeStart),
+		"recipe.next":      w.recipeMove(1),
+		"recipe.repeat":    w.recipeMove(0),
+		"recipe.previous":  w.recipeMove(-1),
+		"recipe.restart":   Action(w.recipeRestart),
+		"favorites.add":    w.withStore(w.favoritesAdd),
+		"favorites.list":   w.withStore(w.favoritesList),
+		"favorites.remove": w.withStore(w.favoritesRemove),
+		"history.list":     w.withStore(w.historyList),
+	}
+	return w
+}
+
+// Actions returns the sorted names of the actions handled by the webhook
+func (w *Webhook) Actions() []string {
+	as := make([]string, 0, len(w.actions))
+	for n := range w.actions {
+		as = append(as, n)
+	}
+	sort.Strings(as)
+	return as
+}
+
+// Register adds the webhook, probe and metrics routes to the given router
+func (w *Webhook) Register(r gin.IRouter) {
+	r.POST("/webhook", w.Handle)
+	r.GET("/healthz", w.Healthz)
+	r.GET("/readyz", w.Readyz)
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+}
+
+// localizer returns the localizer matching the language of the request
+func (w *Webhook) localizer(dfr *fulfillment.Request) *i18n.Localizer {
+	return w.Options.Catalog.For(dfr.Language)
+}
+
+// statusError is an error that should be answered with a specific status
+type statusError struct {
+	status int
+	msg    string
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+func badRequest(err error, msg string) error {
+	return &statusError{status: http.StatusBadRequest, msg: msg, err: err}
+}
+
+// transition sets the page or flow a Dialogflow CX session transitions to after
+// the given action, unless the action already chose one. The response is
+// copied since it may be shared with the late cache.
+func (w *Webhook) transition(name string, dff *fulfillment.Response) *fulfillment.Response {
+	t, ok := w.Options.Transitions[name]
+	if !ok || dff.TargetPage != "" || dff.TargetFlow != "" {
+		return dff
+	}
+	r := *dff
+	if strings.Contains(t, "/pages/") {
+		r.TargetPage = t
+	} else {
+		r.TargetFlow = t
+	}
+	return &r
+}
+
+// Handle is the gin handler receiving the Dialogflow requests and routing them
+// to the right action, Dialogflow CX requests being routed with their
+// fulfillment tag. Every call is recorded if a store is configured.
+func (w *Webhook) Handle(c *gin.Context) {
+	var err error
+	var body []byte
+	var dfr *fulfillment.Request
+	var dff *fulfillment.Response
+	var timedOut bool
+
+	start := time.Now()
+	outcome := store.OutcomeOK
+	defer func() { w.record(dfr, start, outcome) }()
+
+	if body, err = io.ReadAll(c.Request.Body); err != nil {
+		logrus.WithError(err).Warn("Couldn't read request")
+		outcome = store.OutcomeInvalid
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	codec := w.codec
+	if fulfillment.IsCX(body) {
+		codec = w.cx
+	}
+	if dfr, err = codec.Decode(bytes.NewReader(body)); err != nil {
+		logrus.WithError(err).Warn("Couldn't decode request")
+		outcome = store.OutcomeInvalid
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	name := dfr.Action
+	clog := logrus.WithField("action", name)
+
+	a, ok := w.actions[name]
+	if !ok {
+		clog.Warn("Unknown")
+		outcome = store.OutcomeUnknown
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	clog.Info("Detected")
+
+	if dff, timedOut, err = w.run(name, a, dfr); err != nil {
+		var se *statusError
+		if errors.As(err, &se) {
+			clog.WithError(se.err).Error(se.msg)
+			outcome = store.OutcomeBadRequest
+			c.AbortWithStatus(se.status)
+			return
+		}
+		clog.WithError(err).Error("Couldn't run action")
+		outcome = store.OutcomeError
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	if timedOut {
+		outcome = store.OutcomeTimeout
+	} else {
+		dff = w.transition(name, dff)
+	}
+	var b bytes.Buffer
+	if err = codec.Encode(&b, dff); err != nil {
+		clog.WithError(err).Error("Couldn't encode response")
+		outcome = store.OutcomeError
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", b.Bytes())
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+	"github.com/Depado/articles/code/dialogflowpb/params"
+	"github.com/Depado/articles/code/dialogflowpb/response"
+)
+
+const searchContext = "search-followup"
+
+// searchParams are the parameters of the search intents, the original text
+// being what the user actually said
+type searchParams struct {
+	Alcohol            string `df:"alcohol,omitempty"`
+	AlcoholOriginal    string `df:"alcohol,original,omitempty"`
+	DrinkType          string `df:"drink-type,omitempty"`
+	DrinkTypeOriginal  string `df:"drink-type,original,omitempty"`
+	Ingredient         string `df:"ingredient,omitempty"`
+	IngredientOriginal string `df:"ingredient,original,omitempty"`
+	Glass              string `df:"glass,omitempty"`
+	GlassOriginal      string `df:"glass,original,omitempty"`
+}
+
+// merge overrides the parameters with the non-empty ones of o
+func (p *searchParams) merge(o searchParams) {
+	if o.Alcohol != "" {
+		p.Alcohol, p.AlcoholOriginal = o.Alcohol, o.AlcoholOriginal
+	}
+	if o.DrinkType != "" {
+		p.DrinkType, p.DrinkTypeOriginal = o.DrinkType, o.DrinkTypeOriginal
+	}
+	if o.Ingredient != "" {
+		p.Ingredient, p.IngredientOriginal = o.Ingredient, o.IngredientOriginal
+	}
+	if o.Glass != "" {
+		p.Glass, p.GlassOriginal = o.Glass, o.GlassOriginal
+	}
+}
+
+// summary describes the search
+func (p searchParams) summary() string {
+	var parts []string
+	for _, kv := range [][2]string{
+		{"alcohol", p.Alcohol},
+		{"drink-type", p.DrinkType},
+		{"ingredient", p.Ingredient},
+		{"glass", p.Glass},
+	} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+": "+kv[1])
+		}
+	}
+	if len(parts) == 0 {
+		return "anything"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// findContext returns the context with the given short name or nil
+func findContext(wr *dialogflow.WebhookRequest, name string) *dialogflow.Context {
+	for _, c := range wr.GetQueryResult().GetOutputContexts() {
+		if path.Base(c.GetName()) == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// searching builds the response to a search with the given parameters,
+// keeping them in a context so that the user can refine the search
+func searching(wr *dialogflow.WebhookRequest, ps searchParams) (*dialogflow.WebhookResponse, error) {
+	summary := ps.summary()
+	b := response.New(wr).
+		Text("Looking for a cocktail with "+summary+".").
+		Card("Your search", summary, "", response.Button{
+			Text:     "Browse TheCocktailDB",
+			Postback: "https://www.thecocktaildb.com/",
+		}).
+		Context(searchContext, 2, ps).
+		Payload(map[string]interface{}{
+			"google": map[string]interface{}{"expectUserResponse": true},
+		})
+	if ps.Alcohol == "" {
+		b.QuickReplies("Do you want some alcohol?", "With alcohol", "Without alcohol")
+	}
+	return b.Build()
+}
+
+// search answers the initial search with its parameters
+func search(ctx context.Context, wr *dialogflow.WebhookRequest) (*dialogflow.WebhookResponse, error) {
+	var ps searchParams
+	if err := params.Decode(wr.GetQueryResult().GetParameters(), &ps); err != nil {
+		return nil, badRequest(err)
+	}
+	return searching(wr, ps)
+}
+
+// specify refines the previous search, the new parameters overriding the ones
+// stored in the context
+func specify(ctx context.Context, wr *dialogflow.WebhookRequest) (*dialogflow.WebhookResponse, error) {
+	var ps, nps searchParams
+	if err := params.Decode(findContext(wr, searchContext).GetParameters(), &ps); err != nil {
+		return nil, badRequest(err)
+	}
+	if err := params.Decode(wr.GetQueryResult().GetParameters(), &nps); err != nil {
+		return nil, badRequest(err)
+	}
+	ps.merge(nps)
+	return searching(wr, ps)
+}
+
+// random answers with a random drink of TheCocktailDB
+func random(ctx context.Context, wr *dialogflow.WebhookRequest) (*dialogflow.WebhookResponse, error) {
+	d, err := randomDrink(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get random drink: %v", err)
+	}
+	return response.New(wr).
+		Text("I found that cocktail : "+d.Name).
+		Platform(dialogflow.Intent_Message_ACTIONS_ON_GOOGLE).
+		Card(d.Name, d.Instructions, d.Thumb).
+		Build()
+}
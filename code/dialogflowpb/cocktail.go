@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// randomURL is the TheCocktailDB endpoint returning a random drink
+const randomURL = "https://www.thecocktaildb.com/api/json/v1/1/random.php"
+
+// drink holds the fields of a TheCocktailDB drink used in the responses
+type drink struct {
+	ID           string `json:"idDrink"`
+	Name         string `json:"strDrink"`
+	Instructions string `json:"strInstructions"`
+	Thumb        string `json:"strDrinkThumb"`
+}
+
+// randomDrink fetches a random drink
+func randomDrink(ctx context.Context) (*drink, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, randomURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var dl struct {
+		Drinks []*drink `json:"drinks"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&dl); err != nil {
+		return nil, err
+	}
+	if len(dl.Drinks) == 0 {
+		return nil, errors.New("no drink returned")
+	}
+	return dl.Drinks[0], nil
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -8,18 +11,78 @@ import (
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+	"github.com/Depado/articles/code/dialogflowpb/response"
 )
 
+// Handler answers the webhook requests of a single action. It is the
+// fulfillment.Handler of the dialogflow webhook using the protobuf types
+// directly, since that webhook can't be imported from this module.
+type Handler interface {
+	Fulfill(ctx context.Context, wr *dialogflow.WebhookRequest) (*dialogflow.WebhookResponse, error)
+}
+
+// HandlerFunc turns a function into a Handler
+type HandlerFunc func(ctx context.Context, wr *dialogflow.WebhookRequest) (*dialogflow.WebhookResponse, error)
+
+// Fulfill calls f
+func (f HandlerFunc) Fulfill(ctx context.Context, wr *dialogflow.WebhookRequest) (*dialogflow.WebhookResponse, error) {
+	return f(ctx, wr)
+}
+
+// errBadRequest is wrapped by the errors caused by an invalid request
+var errBadRequest = errors.New("invalid request")
+
+func badRequest(err error) error {
+	return fmt.Errorf("%w: %v", errBadRequest, err)
+}
+
+// actions are the same as the ones of the dialogflow webhook
+var actions = map[string]Handler{
+	"search":         HandlerFunc(search),
+	"search.specify": HandlerFunc(specify),
+	"random":         HandlerFunc(random),
+}
+
 func handleWebhook(c *gin.Context) {
 	var err error
 
 	wr := dialogflow.WebhookRequest{}
-	if err = jsonpb.Unmarshal(c.Request.Body, &wr); err != nil {
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err = u.Unmarshal(c.Request.Body, &wr); err != nil {
 		logrus.WithError(err).Error("Couldn't Unmarshal request to jsonpb")
-		c.Status(http.StatusBadRequest)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	name := wr.GetQueryResult().GetAction()
+	clog := logrus.WithField("action", name)
+
+	a, ok := actions[name]
+	if !ok {
+		clog.Warn("Unknown")
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	clog.Info("Detected")
+
+	resp, err := a.Fulfill(c.Request.Context(), &wr)
+	if errors.Is(err, errBadRequest) {
+		clog.WithError(err).Error("Couldn't decode parameters")
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		clog.WithError(err).Error("Couldn't run action")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	var b bytes.Buffer
+	if err = response.Write(&b, resp); err != nil {
+		clog.WithError(err).Error("Couldn't Marshal response to jsonpb")
+		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
-	fmt.Println(wr.GetQueryResult().GetOutputContexts())
+	c.Data(http.StatusOK, "application/json; charset=utf-8", b.Bytes())
 }
 
 func main() {
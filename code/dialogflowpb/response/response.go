@@ -0,0 +1,177 @@
+// Package response provides a fluent builder for the Dialogflow webhook
+// responses using the protobuf types.
+package response
+
+import (
+	"io"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+
+	"github.com/Depado/articles/code/dialogflowpb/params"
+)
+
+// Button is a button of a card, Postback being either the text sent back
+// when clicked or a URL to open
+type Button struct {
+	Text     string
+	Postback string
+}
+
+// Builder builds a WebhookResponse. The first error encountered is kept and
+// returned by Build so that the calls can be chained.
+type Builder struct {
+	session  string
+	platform dialogflow.Intent_Message_Platform
+	resp     *dialogflow.WebhookResponse
+	err      error
+}
+
+// New returns a new Builder answering the given request, the session of the
+// request being used to name the output contexts
+func New(wr *dialogflow.WebhookRequest) *Builder {
+	return &Builder{
+		session: wr.GetSession(),
+		resp:    &dialogflow.WebhookResponse{},
+	}
+}
+
+// Platform sets the platform of the messages added afterwards
+func (b *Builder) Platform(p dialogflow.Intent_Message_Platform) *Builder {
+	b.platform = p
+	return b
+}
+
+// Source sets the source of the response
+func (b *Builder) Source(s string) *Builder {
+	b.resp.Source = s
+	return b
+}
+
+func (b *Builder) message(m *dialogflow.Intent_Message) *Builder {
+	m.Platform = b.platform
+	b.resp.FulfillmentMessages = append(b.resp.FulfillmentMessages, m)
+	return b
+}
+
+// Text adds a text message, Dialogflow picking one of the variants. The first
+// text is also used as the fulfillment text if none was set before.
+func (b *Builder) Text(texts ...string) *Builder {
+	if len(texts) == 0 {
+		return b
+	}
+	if b.resp.FulfillmentText == "" {
+		b.resp.FulfillmentText = texts[0]
+	}
+	return b.message(&dialogflow.Intent_Message{
+		Message: &dialogflow.Intent_Message_Text_{
+			Text: &dialogflow.Intent_Message_Text{Text: texts},
+		},
+	})
+}
+
+// Card adds a card message with its optional buttons
+func (b *Builder) Card(title, subtitle, image string, buttons ...Button) *Builder {
+	c := &dialogflow.Intent_Message_Card{
+		Title:    title,
+		Subtitle: subtitle,
+		ImageUri: image,
+	}
+	for _, bt := range buttons {
+		c.Buttons = append(c.Buttons, &dialogflow.Intent_Message_Card_Button{
+			Text:     bt.Text,
+			Postback: bt.Postback,
+		})
+	}
+	return b.message(&dialogflow.Intent_Message{
+		Message: &dialogflow.Intent_Message_Card_{Card: c},
+	})
+}
+
+// QuickReplies adds a quick replies message
+func (b *Builder) QuickReplies(title string, replies ...string) *Builder {
+	return b.message(&dialogflow.Intent_Message{
+		Message: &dialogflow.Intent_Message_QuickReplies_{
+			QuickReplies: &dialogflow.Intent_Message_QuickReplies{
+				Title:        title,
+				QuickReplies: replies,
+			},
+		},
+	})
+}
+
+// Payload sets the custom payload of the response, for example the "google"
+// key holding the Actions on Google specific response. The payload is either a
+// map or a struct, see params.Encode.
+func (b *Builder) Payload(p interface{}) *Builder {
+	s, err := params.Encode(p)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.resp.Payload = s
+	return b
+}
+
+// Context adds an output context. The name is prefixed with the session of the
+// request unless it already is a full context name.
+func (b *Builder) Context(name string, lifespan int32, ps interface{}) *Builder {
+	s, err := params.Encode(ps)
+	if err != nil {
+		return b.fail(err)
+	}
+	if !strings.Contains(name, "/") {
+		name = b.session + "/contexts/" + name
+	}
+	b.resp.OutputContexts = append(b.resp.OutputContexts, &dialogflow.Context{
+		Name:          name,
+		LifespanCount: lifespan,
+		Parameters:    s,
+	})
+	return b
+}
+
+// Event triggers the given event once the response is received, Dialogflow
+// then matches the intent handling it and ignores the messages
+func (b *Builder) Event(name, lang string, ps interface{}) *Builder {
+	s, err := params.Encode(ps)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.resp.FollowupEventInput = &dialogflow.EventInput{
+		Name:         name,
+		LanguageCode: lang,
+		Parameters:   s,
+	}
+	return b
+}
+
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Build returns the response or the first error encountered
+func (b *Builder) Build() (*dialogflow.WebhookResponse, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.resp, nil
+}
+
+// Write builds the response and writes it to w as JSON
+func (b *Builder) Write(w io.Writer) error {
+	r, err := b.Build()
+	if err != nil {
+		return err
+	}
+	return Write(w, r)
+}
+
+// Write writes the response to w using the JSON mapping of the protobuf types
+func Write(w io.Writer, r *dialogflow.WebhookResponse) error {
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, r)
+}
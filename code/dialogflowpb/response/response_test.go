@@ -0,0 +1,138 @@
+package response
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/cloud/dialogflow/v2"
+)
+
+const session = "projects/cocktail-agent/agent/sessions/s"
+
+// roundTrip writes the response built by b and decodes it back
+func roundTrip(t *testing.T, b *Builder) *dialogflow.WebhookResponse {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var r dialogflow.WebhookResponse
+	if err := jsonpb.Unmarshal(&buf, &r); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	return &r
+}
+
+func TestBuilder(t *testing.T) {
+	b := New(&dialogflow.WebhookRequest{Session: session}).
+		Source("cocktail").
+		Text("I found a Mojito", "Here is a Mojito").
+		Text("It's made with rum").
+		Platform(dialogflow.Intent_Message_ACTIONS_ON_GOOGLE).
+		Card("Mojito", "Cocktail", "https://example.com/mojito.jpg", Button{Text: "Recipe", Postback: "recipe"}).
+		QuickReplies("What now?", "Another one", "Recipe").
+		Context("recipe", 5, map[string]interface{}{"drink": "11000", "step": 0}).
+		Context(session+"/contexts/search", 0, nil).
+		Payload(map[string]interface{}{"google": map[string]interface{}{"expectUserResponse": true}})
+	r := roundTrip(t, b)
+
+	if r.FulfillmentText != "I found a Mojito" || r.Source != "cocktail" {
+		t.Errorf("got fulfillment text %q and source %q", r.FulfillmentText, r.Source)
+	}
+
+	ms := r.FulfillmentMessages
+	if len(ms) != 4 {
+		t.Fatalf("got %d messages, expected 4", len(ms))
+	}
+	if got := ms[0].GetText().GetText(); len(got) != 2 || got[1] != "Here is a Mojito" || ms[0].Platform != dialogflow.Intent_Message_PLATFORM_UNSPECIFIED {
+		t.Errorf("unexpected first message %v", ms[0])
+	}
+	c := ms[2].GetCard()
+	if c == nil || ms[2].Platform != dialogflow.Intent_Message_ACTIONS_ON_GOOGLE {
+		t.Fatalf("expected an Actions on Google card, got %v", ms[2])
+	}
+	if c.Title != "Mojito" || c.Subtitle != "Cocktail" || c.ImageUri != "https://example.com/mojito.jpg" ||
+		len(c.Buttons) != 1 || c.Buttons[0].Text != "Recipe" || c.Buttons[0].Postback != "recipe" {
+		t.Errorf("unexpected card %v", c)
+	}
+	qr := ms[3].GetQuickReplies()
+	if qr.GetTitle() != "What now?" || len(qr.GetQuickReplies()) != 2 || qr.QuickReplies[1] != "Recipe" {
+		t.Errorf("unexpected quick replies %v", qr)
+	}
+
+	cs := r.OutputContexts
+	if len(cs) != 2 {
+		t.Fatalf("got %d contexts, expected 2", len(cs))
+	}
+	if cs[0].Name != session+"/contexts/recipe" || cs[0].LifespanCount != 5 {
+		t.Errorf("got context %s with lifespan %d", cs[0].Name, cs[0].LifespanCount)
+	}
+	if got := cs[0].Parameters.Fields["drink"].GetStringValue(); got != "11000" {
+		t.Errorf("got drink %q, expected 11000", got)
+	}
+	if cs[1].Name != session+"/contexts/search" || cs[1].LifespanCount != 0 || cs[1].Parameters != nil {
+		t.Errorf("unexpected context %v", cs[1])
+	}
+
+	g := r.Payload.GetFields()["google"].GetStructValue()
+	if !g.GetFields()["expectUserResponse"].GetBoolValue() {
+		t.Errorf("unexpected payload %v", r.Payload)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	type params struct {
+		Drink string `df:"drink"`
+	}
+	r := roundTrip(t, New(&dialogflow.WebhookRequest{Session: session}).Event("RECIPE", "fr", params{Drink: "11000"}))
+	e := r.FollowupEventInput
+	if e.GetName() != "RECIPE" || e.GetLanguageCode() != "fr" || e.GetParameters().GetFields()["drink"].GetStringValue() != "11000" {
+		t.Errorf("unexpected event %v", e)
+	}
+	if len(r.FulfillmentMessages) != 0 || r.FulfillmentText != "" {
+		t.Errorf("unexpected messages %v", r.FulfillmentMessages)
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	tests := []struct {
+		name string
+		b    func(*Builder) *Builder
+	}{
+		{"payload channel", func(b *Builder) *Builder { return b.Payload(map[string]interface{}{"c": make(chan int)}) }},
+		{"payload not a struct", func(b *Builder) *Builder { return b.Payload(42) }},
+		{"context", func(b *Builder) *Builder { return b.Context("recipe", 1, []string{"a"}) }},
+		{"event", func(b *Builder) *Builder { return b.Event("RECIPE", "en", "drink") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The first error is kept whatever is built afterwards
+			b := tt.b(New(&dialogflow.WebhookRequest{Session: session}).Text("hello")).Text("world")
+			if r, err := b.Build(); err == nil || r != nil {
+				t.Fatalf("got %v and %v, expected an error", r, err)
+			}
+			var buf bytes.Buffer
+			if err := b.Write(&buf); err == nil || buf.Len() != 0 {
+				t.Errorf("got %v and %q written, expected an error", err, buf.String())
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	r := &dialogflow.WebhookResponse{FulfillmentText: "hello"}
+	if err := Write(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"fulfillmentText":"hello"`) {
+		t.Errorf("unexpected JSON %s", buf.String())
+	}
+	var got dialogflow.WebhookResponse
+	if err := jsonpb.Unmarshal(&buf, &got); err != nil || !proto.Equal(&got, r) {
+		t.Errorf("got %v (%v), expected %v", &got, err, r)
+	}
+}
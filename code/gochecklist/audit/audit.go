@@ -0,0 +1,128 @@
+// Package audit checks an existing Go repository against the checklist:
+// license, commands and configuration, version injection, Dockerfile,
+// dependency lockfile and logger setup.
+package audit
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Severity is the level of a failed check, using the SARIF levels
+type Severity string
+
+// Severities of the rules
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Status is the outcome of a check
+type Status string
+
+// Outcomes of a check, a check being skipped when it doesn't apply
+const (
+	Pass Status = "pass"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// Rule is an item of the checklist
+type Rule struct {
+	ID       string
+	Title    string
+	Severity Severity
+	// Fix is the hint given when the check fails
+	Fix   string
+	check func(r *repo) finding
+}
+
+// finding is the outcome of a rule, file being the file it relates to if any
+type finding struct {
+	status Status
+	msg    string
+	file   string
+}
+
+func pass(file, format string, args ...interface{}) finding {
+	return finding{status: Pass, msg: fmt.Sprintf(format, args...), file: file}
+}
+
+func fail(file, format string, args ...interface{}) finding {
+	return finding{status: Fail, msg: fmt.Sprintf(format, args...), file: file}
+}
+
+func skip(format string, args ...interface{}) finding {
+	return finding{status: Skip, msg: fmt.Sprintf(format, args...)}
+}
+
+// Result is the outcome of a rule on a repository
+type Result struct {
+	Rule     string   `json:"rule"`
+	Title    string   `json:"title"`
+	Severity Severity `json:"severity"`
+	Status   Status   `json:"status"`
+	Message  string   `json:"message"`
+	// File is the path of the file the result relates to, relative to the
+	// repository
+	File string `json:"file,omitempty"`
+	// Fix is only set when the check failed
+	Fix string `json:"fix,omitempty"`
+}
+
+// Failed returns whether the check failed with at least the given severity
+func (r Result) Failed(min Severity) bool {
+	return r.Status == Fail && (min == Warning || r.Severity == Error)
+}
+
+// Audit runs every rule on the repository in dir
+func Audit(dir string) ([]Result, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s isn't a directory", dir)
+	}
+	r, err := scan(dir)
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]Result, 0, len(Rules))
+	for _, rule := range Rules {
+		f := rule.check(r)
+		res := Result{
+			Rule:     rule.ID,
+			Title:    rule.Title,
+			Severity: rule.Severity,
+			Status:   f.status,
+			Message:  f.msg,
+			File:     f.file,
+		}
+		if f.status == Fail {
+			res.Fix = rule.Fix
+		}
+		rs = append(rs, res)
+	}
+	return rs, nil
+}
+
+// Summary counts the results by status
+func Summary(rs []Result) map[Status]int {
+	s := map[Status]int{Pass: 0, Fail: 0, Skip: 0}
+	for _, r := range rs {
+		s[r.Status]++
+	}
+	return s
+}
+
+// sortedKeys returns the keys of a set, sorted
+func sortedKeys(m map[string]bool) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// statuses returns the status of every result by rule
+func statuses(rs []Result) map[string]Status {
+	m := make(map[string]Status, len(rs))
+	for _, r := range rs {
+		m[r.Rule] = r.Status
+	}
+	return m
+}
+
+func TestAudit(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want map[string]Status
+	}{
+		{"complete", map[string]Status{
+			"license": Pass, "cobra": Pass, "viper": Pass, "ldflags": Pass,
+			"docker-multistage": Pass, "docker-final-image": Pass, "lockfile": Pass, "logger": Pass,
+		}},
+		{"nosum", map[string]Status{
+			"license": Fail, "cobra": Pass, "viper": Fail, "ldflags": Fail,
+			"docker-multistage": Fail, "docker-final-image": Skip, "lockfile": Fail, "logger": Fail,
+		}},
+		{"stdlib", map[string]Status{
+			"license": Fail, "cobra": Fail, "viper": Fail, "ldflags": Fail,
+			"docker-multistage": Fail, "docker-final-image": Skip, "lockfile": Pass, "logger": Pass,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			rs, err := Audit(filepath.Join("testdata", tt.dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := statuses(rs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, expected %v", got, tt.want)
+			}
+			for _, r := range rs {
+				if (r.Status == Fail) != (r.Fix != "") {
+					t.Errorf("%s: fix %q with status %s", r.Rule, r.Fix, r.Status)
+				}
+			}
+		})
+	}
+
+	if _, err := Audit(filepath.Join("testdata", "complete", "go.mod")); err == nil {
+		t.Error("expected an error auditing a file")
+	}
+}
+
+func TestLockfile(t *testing.T) {
+	rs, err := Audit(filepath.Join("testdata", "nosum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rs {
+		if r.Rule == "lockfile" && r.Message != "github.com/sirupsen/logrus is imported but there is no go.sum" {
+			t.Errorf("unexpected message %q", r.Message)
+		}
+	}
+}
+
+func TestStages(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		stages     []stage
+		final      string
+	}{
+		{
+			"single",
+			"FROM golang:1.22\nRUN go build\n",
+			[]stage{{image: "golang:1.22"}},
+			"golang:1.22",
+		},
+		{
+			"platform and alias",
+			"FROM --platform=$BUILDPLATFORM golang:1.22 AS build\nRUN go build\n\nfrom scratch\nCOPY --from=build /app /app\n",
+			[]stage{{image: "golang:1.22", name: "build"}, {image: "scratch"}},
+			"scratch",
+		},
+		{
+			"based on a previous stage",
+			"FROM golang:1.22 AS build\nFROM alpine:3.19 AS Base\nFROM base\n",
+			[]stage{{image: "golang:1.22", name: "build"}, {image: "alpine:3.19", name: "base"}, {image: "base"}},
+			"alpine:3.19",
+		},
+		{
+			"library prefix",
+			"FROM golang AS build\nFROM docker.io/library/alpine\n",
+			[]stage{{image: "golang", name: "build"}, {image: "docker.io/library/alpine"}},
+			"alpine",
+		},
+		{
+			"short library prefix",
+			"FROM library/debian:12\n",
+			[]stage{{image: "library/debian:12"}},
+			"debian:12",
+		},
+		{
+			"distroless",
+			"FROM golang AS build\nFROM gcr.io/distroless/static\n",
+			[]stage{{image: "golang", name: "build"}, {image: "gcr.io/distroless/static"}},
+			"gcr.io/distroless/static",
+		},
+		{
+			"no from",
+			"# FROM golang\nRUN true\nFROM\n",
+			nil,
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := stages(tt.dockerfile)
+			if !reflect.DeepEqual(ss, tt.stages) {
+				t.Fatalf("got stages %+v, expected %+v", ss, tt.stages)
+			}
+			if len(ss) == 0 {
+				return
+			}
+			if got := finalImage(ss); got != tt.final {
+				t.Errorf("got final image %q, expected %q", got, tt.final)
+			}
+		})
+	}
+}
+
+func TestLdflagsX(t *testing.T) {
+	tests := []struct {
+		in    string
+		match bool
+	}{
+		{`-ldflags "-X main.Version=$(VERSION)"`, true},
+		{`-ldflags="-X=main.Version=1.0"`, true},
+		{`-ldflags '-X 'github.com/me/prog/cmd.Build=$(BUILD)''`, true},
+		{`-ldflags "-X github.com/me/prog/internal/version.Version=1"`, true},
+		{`-ldflags "-s -w"`, false},
+		{`-X Version=1`, false},
+	}
+	for _, tt := range tests {
+		if got := ldflagsX.MatchString(tt.in); got != tt.match {
+			t.Errorf("%s: got %v, expected %v", tt.in, got, tt.match)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		status Status
+	}{
+		{"logrus configured", map[string]string{
+			"main.go": "package main\n\nimport \"github.com/sirupsen/logrus\"\n\nfunc main() { logrus.SetFormatter(&logrus.JSONFormatter{}) }\n",
+		}, Pass},
+		{"logrus not configured", map[string]string{
+			"main.go": "package main\n\nimport \"github.com/sirupsen/logrus\"\n\nfunc main() { logrus.Info(\"hi\") }\n",
+		}, Fail},
+		{"zap", map[string]string{
+			"main.go": "package main\n\nimport \"go.uber.org/zap\"\n\nfunc main() { zap.NewProduction() }\n",
+		}, Pass},
+		{"zerolog", map[string]string{
+			"main.go": "package main\n\nimport \"github.com/rs/zerolog\"\n\nfunc main() { zerolog.SetGlobalLevel(zerolog.InfoLevel) }\n",
+		}, Pass},
+		{"configured in another file", map[string]string{
+			"main.go":       "package main\n\nimport \"github.com/sirupsen/logrus\"\n\nfunc main() { logrus.Info(\"hi\") }\n",
+			"setup/log.go":  "package setup\n\nimport \"github.com/sirupsen/logrus\"\n\nfunc Init() { logrus.SetLevel(logrus.DebugLevel) }\n",
+			"vendor/x/x.go": "package x\n\nimport \"log/slog\"\n\nfunc init() { slog.SetDefault(nil) }\n",
+		}, Pass},
+		{"vendored only", map[string]string{
+			"vendor/x/x.go": "package x\n\nimport \"log/slog\"\n\nfunc init() { slog.SetDefault(nil) }\n",
+		}, Fail},
+		{"none", map[string]string{
+			"main.go": "package main\n\nimport \"log\"\n\nfunc main() { log.Println(\"hi\") }\n",
+		}, Fail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for n, c := range tt.files {
+				p := filepath.Join(dir, filepath.FromSlash(n))
+				if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(c), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r, err := scan(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f := checkLogger(r); f.status != tt.status {
+				t.Errorf("got %s (%s), expected %s", f.status, f.msg, tt.status)
+			}
+		})
+	}
+}
+
+func TestFailed(t *testing.T) {
+	tests := []struct {
+		r       Result
+		error   bool
+		warning bool
+	}{
+		{Result{Severity: Error, Status: Fail}, true, true},
+		{Result{Severity: Warning, Status: Fail}, false, true},
+		{Result{Severity: Error, Status: Pass}, false, false},
+		{Result{Severity: Warning, Status: Skip}, false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Failed(Error); got != tt.error {
+			t.Errorf("%+v: Failed(error) = %v, expected %v", tt.r, got, tt.error)
+		}
+		if got := tt.r.Failed(Warning); got != tt.warning {
+			t.Errorf("%+v: Failed(warning) = %v, expected %v", tt.r, got, tt.warning)
+		}
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	rs, err := Audit(filepath.Join("testdata", "nosum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := WriteSARIF(&b, Tool{Name: "myprogram", Version: "1.0.0", URI: "https://example.com"}, rs); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "nosum.sarif")
+	if *update {
+		if err := os.WriteFile(golden, b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("SARIF output differs from %s, run go test -update to update it:\n%s", golden, b.String())
+	}
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// skipped are the directories that aren't part of the project sources
+var skipped = map[string]bool{"vendor": true, "testdata": true, "node_modules": true}
+
+// repo is a scanned repository
+type repo struct {
+	dir string
+	// names maps the lower cased names of the files at the root to their
+	// actual name
+	names map[string]string
+	// imports maps the imported packages to the files importing them
+	imports map[string][]string
+}
+
+// scan lists the files at the root of dir and parses the imports of every Go
+// file, ignoring the hidden and vendored directories
+func scan(dir string) (*repo, error) {
+	r := &repo{dir: dir, names: map[string]string{}, imports: map[string][]string{}}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			r.names[strings.ToLower(fi.Name())] = fi.Name()
+		}
+	}
+
+	fset := token.NewFileSet()
+	err = filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if p != dir && (skipped[fi.Name()] || strings.HasPrefix(fi.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) != ".go" {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, p, nil, parser.ImportsOnly)
+		if err != nil {
+			// Files that don't parse are reported by the compiler, not here
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		for _, is := range f.Imports {
+			if path, err := strconv.Unquote(is.Path.Value); err == nil {
+				r.imports[path] = append(r.imports[path], rel)
+			}
+		}
+		return nil
+	})
+	return r, err
+}
+
+// find returns the actual name of the first file found at the root among
+// names, compared case insensitively
+func (r *repo) find(names ...string) string {
+	for _, n := range names {
+		if actual, ok := r.names[strings.ToLower(n)]; ok {
+			return actual
+		}
+	}
+	return ""
+}
+
+// read returns the content of a file relative to the repository
+func (r *repo) read(name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(r.dir, filepath.FromSlash(name)))
+	return string(b), err
+}
+
+// importers returns the files importing path or one of its packages, sorted
+func (r *repo) importers(path string) []string {
+	fs := make(map[string]bool)
+	for p, files := range r.imports {
+		if p == path || strings.HasPrefix(p, path+"/") {
+			for _, f := range files {
+				fs[f] = true
+			}
+		}
+	}
+	return sortedKeys(fs)
+}
+
+// thirdParty returns the imported packages that are neither part of the
+// standard library nor of module, sorted. Like the go command, the paths
+// whose first element has no dot are considered standard.
+func (r *repo) thirdParty(module string) []string {
+	ps := make(map[string]bool)
+	for p := range r.imports {
+		if !strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+			continue
+		}
+		if module != "" && (p == module || strings.HasPrefix(p, module+"/")) {
+			continue
+		}
+		ps[p] = true
+	}
+	return sortedKeys(ps)
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText writes the results as a table followed by the fix hints of the
+// failed checks and a summary
+func WriteText(w io.Writer, rs []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, r := range rs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.ToUpper(string(r.Status)), r.Rule, r.Severity, r.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	hints := false
+	for _, r := range rs {
+		if r.Status != Fail {
+			continue
+		}
+		if !hints {
+			fmt.Fprintln(w, "\nFixes:")
+			hints = true
+		}
+		fmt.Fprintf(w, "  %s: %s\n", r.Rule, r.Fix)
+	}
+	s := Summary(rs)
+	_, err := fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n", s[Pass], s[Fail], s[Skip])
+	return err
+}
+
+// WriteJSON writes the results as an indented JSON document
+func WriteJSON(w io.Writer, path string, rs []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Path    string         `json:"path"`
+		Results []Result       `json:"results"`
+		Summary map[Status]int `json:"summary"`
+	}{path, rs, Summary(rs)})
+}
+
+// Tool describes the program in the SARIF report
+type Tool struct {
+	Name    string
+	Version string
+	URI     string
+}
+
+// SARIF 2.1.0 log, limited to the properties used by the report
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri,omitempty"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string       `json:"id"`
+		Name                 string       `json:"name"`
+		ShortDescription     sarifMessage `json:"shortDescription"`
+		Help                 sarifMessage `json:"help"`
+		DefaultConfiguration struct {
+			Level Severity `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     Severity        `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+		} `json:"physicalLocation"`
+	}
+)
+
+// WriteSARIF writes the failed checks as a SARIF 2.1.0 log, for code scanning
+// tools. The rules are described with their fix as help.
+func WriteSARIF(w io.Writer, t Tool, rs []Result) error {
+	d := sarifDriver{Name: t.Name, Version: t.Version, InformationURI: t.URI}
+	index := make(map[string]int)
+	for i, r := range Rules {
+		sr := sarifRule{
+			ID:               r.ID,
+			Name:             r.Title,
+			ShortDescription: sarifMessage{r.Title},
+			Help:             sarifMessage{r.Fix},
+		}
+		sr.DefaultConfiguration.Level = r.Severity
+		d.Rules = append(d.Rules, sr)
+		index[r.ID] = i
+	}
+
+	run := sarifRun{Tool: sarifTool{d}, Results: []sarifResult{}}
+	for _, r := range rs {
+		if r.Status != Fail {
+			continue
+		}
+		sr := sarifResult{
+			RuleID:    r.Rule,
+			RuleIndex: index[r.Rule],
+			Level:     r.Severity,
+			Message:   sarifMessage{r.Message + ". " + r.Fix},
+		}
+		// Code scanning rejects the results without location, the ones
+		// without file relate to the repository itself
+		var l sarifLocation
+		l.PhysicalLocation.ArtifactLocation.URI = "."
+		if r.File != "" {
+			l.PhysicalLocation.ArtifactLocation.URI = r.File
+		}
+		sr.Locations = append(sr.Locations, l)
+		run.Results = append(run.Results, sr)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,259 @@
+package audit
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rules are the items of the checklist, in the order they are reported
+var Rules = []Rule{
+	{
+		ID:       "license",
+		Title:    "License",
+		Severity: Error,
+		Fix:      "Choose a license on https://choosealicense.com/ and add it as LICENSE at the root",
+		check:    checkLicense,
+	},
+	{
+		ID:       "cobra",
+		Title:    "Commands with cobra",
+		Severity: Warning,
+		Fix:      "Define the commands and flags with github.com/spf13/cobra, with a root command in cmd/root.go",
+		check:    checkImport("github.com/spf13/cobra"),
+	},
+	{
+		ID:       "viper",
+		Title:    "Configuration with viper",
+		Severity: Warning,
+		Fix:      "Bind the flags, environment and configuration file with github.com/spf13/viper",
+		check:    checkImport("github.com/spf13/viper"),
+	},
+	{
+		ID:       "ldflags",
+		Title:    "Version injected with ldflags",
+		Severity: Error,
+		Fix:      `Build with LDFLAGS=-ldflags "-X main.Version=$(VERSION) -X main.Build=$(BUILD)" in the Makefile`,
+		check:    checkLdflags,
+	},
+	{
+		ID:       "docker-multistage",
+		Title:    "Multi-stage Dockerfile",
+		Severity: Warning,
+		Fix:      "Build the binary in a golang stage and copy it in the final stage with COPY --from",
+		check:    checkMultistage,
+	},
+	{
+		ID:       "docker-final-image",
+		Title:    "Minimal final image",
+		Severity: Warning,
+		Fix:      "Base the final stage on scratch, alpine or a distroless image",
+		check:    checkFinalImage,
+	},
+	{
+		ID:       "lockfile",
+		Title:    "Dependency lockfile",
+		Severity: Error,
+		Fix:      "Commit go.sum, run go mod tidy to create it, or Gopkg.lock when using dep",
+		check:    checkLockfile,
+	},
+	{
+		ID:       "logger",
+		Title:    "Logger setup",
+		Severity: Warning,
+		Fix:      "Use a structured logger and configure its level and format from the configuration on startup",
+		check:    checkLogger,
+	},
+}
+
+func checkLicense(r *repo) finding {
+	if f := r.find("LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "COPYING"); f != "" {
+		return pass(f, "%s found", f)
+	}
+	return fail("", "no license file at the root")
+}
+
+func checkImport(path string) func(r *repo) finding {
+	return func(r *repo) finding {
+		fs := r.importers(path)
+		switch len(fs) {
+		case 0:
+			return fail("", "%s isn't imported", path)
+		case 1:
+			return pass(fs[0], "%s imported in %s", path, fs[0])
+		}
+		return pass(fs[0], "%s imported in %s and %d more", path, fs[0], len(fs)-1)
+	}
+}
+
+// makefiles are the names make looks for
+var makefiles = []string{"GNUmakefile", "makefile", "Makefile"}
+
+// ldflagsX matches an -X flag setting a variable
+var ldflagsX = regexp.MustCompile(`-X[ =]?['"]?[\w./-]+\.\w+=`)
+
+func checkLdflags(r *repo) finding {
+	mf := r.find(makefiles...)
+	if mf == "" {
+		return fail("", "no Makefile")
+	}
+	c, err := r.read(mf)
+	if err != nil {
+		return fail(mf, "couldn't read %s: %v", mf, err)
+	}
+	if !strings.Contains(c, "-ldflags") || !ldflagsX.MatchString(c) {
+		return fail(mf, "%s doesn't inject variables with -ldflags -X", mf)
+	}
+	return pass(mf, "%s injects variables with -ldflags -X", mf)
+}
+
+// stage is a build stage of a Dockerfile
+type stage struct {
+	image string
+	name  string
+}
+
+// stages parses the FROM instructions of a Dockerfile
+func stages(dockerfile string) []stage {
+	var ss []stage
+	for _, l := range strings.Split(dockerfile, "\n") {
+		fs := strings.Fields(l)
+		if len(fs) < 2 || !strings.EqualFold(fs[0], "FROM") {
+			continue
+		}
+		fs = fs[1:]
+		for len(fs) > 0 && strings.HasPrefix(fs[0], "--") {
+			fs = fs[1:]
+		}
+		if len(fs) == 0 {
+			continue
+		}
+		s := stage{image: strings.ToLower(fs[0])}
+		if len(fs) >= 3 && strings.EqualFold(fs[1], "AS") {
+			s.name = strings.ToLower(fs[2])
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+// dockerfile reads the Dockerfile at the root and parses its stages
+func (r *repo) dockerfile() (string, []stage, error) {
+	df := r.find("Dockerfile")
+	if df == "" {
+		return "", nil, nil
+	}
+	c, err := r.read(df)
+	return df, stages(c), err
+}
+
+func checkMultistage(r *repo) finding {
+	df, ss, err := r.dockerfile()
+	switch {
+	case df == "":
+		return fail("", "no Dockerfile")
+	case err != nil:
+		return fail(df, "couldn't read %s: %v", df, err)
+	case len(ss) < 2:
+		return fail(df, "%s has a single stage", df)
+	}
+	return pass(df, "%s has %d stages", df, len(ss))
+}
+
+// minimalImages are the prefixes of the images considered minimal
+var minimalImages = []string{"scratch", "alpine", "busybox", "gcr.io/distroless/", "cgr.dev/chainguard/"}
+
+func checkFinalImage(r *repo) finding {
+	df, ss, err := r.dockerfile()
+	switch {
+	case df == "":
+		return skip("no Dockerfile")
+	case err != nil:
+		return fail(df, "couldn't read %s: %v", df, err)
+	case len(ss) == 0:
+		return fail(df, "%s has no FROM instruction", df)
+	}
+	img := finalImage(ss)
+	for _, m := range minimalImages {
+		if strings.HasPrefix(img, m) {
+			return pass(df, "final image is %s", img)
+		}
+	}
+	return fail(df, "final image %s isn't minimal", img)
+}
+
+// finalImage returns the image of the last stage, resolving the stages based
+// on a previous one
+func finalImage(ss []stage) string {
+	img := ss[len(ss)-1].image
+	for i := len(ss) - 2; i >= 0; i-- {
+		if ss[i].name != "" && ss[i].name == img {
+			img = ss[i].image
+		}
+	}
+	for _, p := range []string{"docker.io/library/", "docker.io/", "library/"} {
+		img = strings.TrimPrefix(img, p)
+	}
+	return img
+}
+
+// goModRequire matches a require directive of go.mod
+var goModRequire = regexp.MustCompile(`(?m)^require\b`)
+
+// goModModule matches the module directive of go.mod
+var goModModule = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+func checkLockfile(r *repo) finding {
+	if f := r.find("go.sum", "Gopkg.lock", "glide.lock"); f != "" {
+		return pass(f, "%s found", f)
+	}
+	mod := r.find("go.mod")
+	if mod == "" {
+		return fail("", "no go.sum nor Gopkg.lock")
+	}
+	c, err := r.read(mod)
+	if err != nil {
+		return fail(mod, "couldn't read %s: %v", mod, err)
+	}
+	var module string
+	if m := goModModule.FindStringSubmatch(c); m != nil {
+		module = m[1]
+	}
+	if deps := r.thirdParty(module); len(deps) > 0 {
+		return fail(mod, "%s is imported but there is no go.sum", deps[0])
+	}
+	if goModRequire.MatchString(c) {
+		return fail(mod, "%s has dependencies but no go.sum", mod)
+	}
+	return pass(mod, "%s has no dependency to lock", mod)
+}
+
+// loggers are the structured loggers along with the calls configuring them
+var loggers = []struct {
+	path  string
+	setup *regexp.Regexp
+}{
+	{"github.com/sirupsen/logrus", regexp.MustCompile(`\.Set(Level|Formatter)\(`)},
+	{"go.uber.org/zap", regexp.MustCompile(`zap\.(New\w*\(|Config\{)|\.Build\(`)},
+	{"github.com/rs/zerolog", regexp.MustCompile(`zerolog\.(SetGlobalLevel|New)\(`)},
+	{"log/slog", regexp.MustCompile(`slog\.(New|SetDefault)\(`)},
+}
+
+func checkLogger(r *repo) finding {
+	var used []string
+	for _, l := range loggers {
+		fs := r.importers(l.path)
+		if len(fs) == 0 {
+			continue
+		}
+		used = append(used, l.path)
+		for _, f := range fs {
+			if c, err := r.read(f); err == nil && l.setup.MatchString(c) {
+				return pass(f, "%s configured in %s", l.path, f)
+			}
+		}
+	}
+	if len(used) == 0 {
+		return fail("", "no structured logger imported")
+	}
+	return fail("", "%s imported but never configured", strings.Join(used, ", "))
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rootCmd = &cobra.Command{Use: "complete"}
+
+func Execute() {
+	logrus.SetLevel(logrus.InfoLevel)
+	viper.AutomaticEnv()
+	rootCmd.Execute()
+}
@@ -0,0 +1,9 @@
+module example.com/complete
+
+go 1.22
+
+require (
+	github.com/sirupsen/logrus v1.9.3
+	github.com/spf13/cobra v1.8.0
+	github.com/spf13/viper v1.18.2
+)
@@ -0,0 +1,7 @@
+package main
+
+import "example.com/complete/cmd"
+
+func main() {
+	cmd.Execute()
+}
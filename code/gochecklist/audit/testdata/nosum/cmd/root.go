@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{Use: "nosum"}
+
+func Execute() {
+	logrus.Info("starting")
+	rootCmd.Execute()
+}
@@ -0,0 +1,7 @@
+package main
+
+import "example.com/nosum/cmd"
+
+func main() {
+	cmd.Execute()
+}
@@ -0,0 +1,106 @@
+// Package buildinfo describes how the binary was built, combining the values
+// injected at compile time with the information embedded by the Go toolchain.
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Dep is a module the binary depends on
+type Dep struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// Info is the build information of the binary
+type Info struct {
+	Version   string `json:"version"`
+	Build     string `json:"build"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Path      string `json:"path,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Dirty     bool   `json:"dirty"`
+	Deps      []Dep  `json:"deps,omitempty"`
+}
+
+// unknown is the value of the variables that weren't injected
+const unknown = "unknown"
+
+// New returns the build information of the binary. The version, build and
+// date are the values injected with -ldflags, the toolchain information being
+// used when they are empty or unknown: the module version, the VCS revision
+// and the VCS commit time.
+func New(version, build, date string) Info {
+	bi, _ := debug.ReadBuildInfo()
+	return merge(version, build, date, bi)
+}
+
+// merge combines the injected values with the information embedded by the
+// toolchain, bi being nil when the binary has none
+func merge(version, build, date string, bi *debug.BuildInfo) Info {
+	i := Info{
+		Version:   version,
+		Build:     build,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+	if bi != nil {
+		i.GoVersion = bi.GoVersion
+		i.Path = bi.Main.Path
+		var vcsTime string
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				i.Revision = s.Value
+			case "vcs.modified":
+				i.Dirty = s.Value == "true"
+			case "vcs.time":
+				vcsTime = s.Value
+			}
+		}
+		if isUnknown(i.Version) && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			i.Version = bi.Main.Version
+		}
+		if isUnknown(i.Build) && i.Revision != "" {
+			i.Build = i.Revision
+		}
+		if isUnknown(i.Date) && vcsTime != "" {
+			i.Date = vcsTime
+		}
+		for _, d := range bi.Deps {
+			dep := Dep{Path: d.Path, Version: d.Version, Sum: d.Sum}
+			if d.Replace != nil {
+				dep.Replace = d.Replace.Path + "@" + d.Replace.Version
+			}
+			i.Deps = append(i.Deps, dep)
+		}
+	}
+	if i.Version == "" {
+		i.Version = unknown
+	}
+	if i.Build == "" {
+		i.Build = unknown
+	}
+	return i
+}
+
+func isUnknown(s string) bool {
+	return s == "" || s == unknown
+}
+
+// Handler returns an http.Handler answering with the build information as
+// JSON
+func (i Info) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(i)
+	})
+}
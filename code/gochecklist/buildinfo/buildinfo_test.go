@@ -0,0 +1,108 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// buildInfo returns the information embedded by the toolchain for a binary
+// built from a VCS checkout
+func buildInfo(version string, settings ...debug.BuildSetting) *debug.BuildInfo {
+	return &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Main:      debug.Module{Path: "github.com/me/myprogram", Version: version},
+		Deps: []*debug.Module{
+			{Path: "github.com/spf13/cobra", Version: "v1.8.0", Sum: "h1:7aJaZx1B85qltLMc546zn58BxxfZdR/W22ej9CFoEf0="},
+			{Path: "github.com/me/lib", Version: "v0.1.0", Replace: &debug.Module{Path: "../lib", Version: ""}},
+		},
+		Settings: settings,
+	}
+}
+
+var vcs = []debug.BuildSetting{
+	{Key: "-ldflags", Value: "-s -w"},
+	{Key: "vcs", Value: "git"},
+	{Key: "vcs.revision", Value: "0123456789abcdef"},
+	{Key: "vcs.time", Value: "2024-03-01T12:00:00Z"},
+	{Key: "vcs.modified", Value: "true"},
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name                 string
+		version, build, date string
+		bi                   *debug.BuildInfo
+		want                 Info
+	}{
+		{
+			"injected values win",
+			"1.2.0", "abc1234", "2024-03-02", buildInfo("v1.1.0", vcs...),
+			Info{Version: "1.2.0", Build: "abc1234", Date: "2024-03-02", Revision: "0123456789abcdef", Dirty: true},
+		},
+		{
+			"vcs fallback",
+			"", "", "", buildInfo("(devel)", vcs...),
+			Info{Version: "unknown", Build: "0123456789abcdef", Date: "2024-03-01T12:00:00Z", Revision: "0123456789abcdef", Dirty: true},
+		},
+		{
+			"unknown values are replaced",
+			"unknown", "unknown", "unknown", buildInfo("v1.1.0", vcs...),
+			Info{Version: "v1.1.0", Build: "0123456789abcdef", Date: "2024-03-01T12:00:00Z", Revision: "0123456789abcdef", Dirty: true},
+		},
+		{
+			"go install without vcs",
+			"", "", "", buildInfo("v1.1.0"),
+			Info{Version: "v1.1.0", Build: "unknown"},
+		},
+		{
+			"clean checkout",
+			"1.2.0", "", "", buildInfo("", debug.BuildSetting{Key: "vcs.revision", Value: "fedcba"}, debug.BuildSetting{Key: "vcs.modified", Value: "false"}),
+			Info{Version: "1.2.0", Build: "fedcba", Revision: "fedcba"},
+		},
+		{
+			"unknown date kept without vcs time",
+			"", "", "unknown", buildInfo(""),
+			Info{Version: "unknown", Build: "unknown", Date: "unknown"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := merge(tt.version, tt.build, tt.date, tt.bi)
+			tt.want.GoVersion = "go1.22.1"
+			tt.want.Path = "github.com/me/myprogram"
+			tt.want.Deps = []Dep{
+				{Path: "github.com/spf13/cobra", Version: "v1.8.0", Sum: "h1:7aJaZx1B85qltLMc546zn58BxxfZdR/W22ej9CFoEf0="},
+				{Path: "github.com/me/lib", Version: "v0.1.0", Replace: "../lib@"},
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, expected %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Without build information only the injected values are known
+	want := Info{Version: "1.2.0", Build: "unknown", GoVersion: runtime.Version()}
+	if got := merge("1.2.0", "", "", nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	i := Info{Version: "1.2.0", Build: "abc1234", GoVersion: "go1.22.1", Dirty: true}
+	rec := httptest.NewRecorder()
+	i.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/version", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("got content type %q", ct)
+	}
+	var got Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, i) {
+		t.Errorf("got %+v, expected %+v", got, i)
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Depado/articles/code/gochecklist/audit"
+)
+
+var (
+	checkFormat string
+	checkFailOn string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [path]",
+	Short: "Audit a Go repository against the checklist",
+	Long: `Audit a Go repository against the checklist: license, cobra and viper,
+version injected with ldflags in the Makefile, multi-stage Dockerfile with a
+minimal final image, dependency lockfile and logger setup.
+
+The report is written as text, JSON or SARIF and the command exits with 1 when
+a check fails with at least the --fail-on severity, for CI gating.`,
+	Args: cobra.MaximumNArgs(1),
+	// The audit doesn't depend on the configuration
+	Annotations: map[string]string{noConfig: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if checkFailOn != string(audit.Error) && checkFailOn != string(audit.Warning) {
+			logrus.Fatalf("Unknown severity %q, expected error or warning", checkFailOn)
+		}
+		rs, err := audit.Audit(path)
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't audit repository")
+		}
+
+		switch checkFormat {
+		case "text":
+			err = audit.WriteText(os.Stdout, rs)
+		case "json":
+			err = audit.WriteJSON(os.Stdout, path, rs)
+		case "sarif":
+			err = audit.WriteSARIF(os.Stdout, audit.Tool{
+				Name:    rootCmd.Name(),
+				Version: info.Version,
+				URI:     "https://github.com/Depado/articles",
+			}, rs)
+		default:
+			err = fmt.Errorf("unknown format %q, expected text, json or sarif", checkFormat)
+		}
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't write report")
+		}
+
+		for _, r := range rs {
+			if r.Failed(audit.Severity(checkFailOn)) {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&checkFormat, "format", "text", "output format, one of text, json or sarif")
+	checkCmd.Flags().StringVar(&checkFailOn, "fail-on", "warning", "lowest severity of the failed checks making the command exit with 1, error or warning")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate the shell completion script",
+	Long: `Generate the shell completion script, written to stdout.
+
+  bash:       myprogram completion bash > /etc/bash_completion.d/myprogram
+  zsh:        myprogram completion zsh > "${fpath[1]}/_myprogram"
+  fish:       myprogram completion fish > ~/.config/fish/completions/myprogram.fish
+  powershell: myprogram completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	Args:      cobra.ExactArgs(1),
+	// The scripts don't depend on the configuration
+	Annotations: map[string]string{noConfig: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		removePlugins()
+		var err error
+		switch args[0] {
+		case "bash":
+			err = rootCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			err = rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			err = rootCmd.GenPowerShellCompletion(os.Stdout)
+		default:
+			err = fmt.Errorf("unknown shell %q, expected bash, zsh, fish or powershell", args[0])
+		}
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't generate completion")
+		}
+	},
+}
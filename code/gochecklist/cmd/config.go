@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/Depado/articles/code/gochecklist/config"
+)
+
+var dumpFormat string
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	// Overrides the root check so that an invalid configuration can be
+	// inspected
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate the configuration and report every error",
+	Run: func(cmd *cobra.Command, args []string) {
+		if confErr != nil {
+			fmt.Fprintln(os.Stderr, confErr)
+			os.Exit(1)
+		}
+		_, ls := currentConf()
+		if fs := config.Files(ls); len(fs) > 0 {
+			fmt.Printf("Configuration is valid (%s)\n", strings.Join(fs, ", "))
+		} else {
+			fmt.Println("Configuration is valid")
+		}
+	},
+}
+
+var configDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Show the effective configuration with the secrets masked",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _ := currentConf()
+		if c == nil {
+			logrus.WithError(confErr).Fatal("Couldn't load configuration")
+		}
+		var out []byte
+		var err error
+		switch dumpFormat {
+		case "yaml":
+			out, err = yaml.Marshal(c.Masked())
+		case "json":
+			if out, err = json.MarshalIndent(c.Masked(), "", "  "); err == nil {
+				out = append(out, '\n')
+			}
+		default:
+			err = fmt.Errorf("unknown format %q, expected yaml or json", dumpFormat)
+		}
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't dump configuration")
+		}
+		os.Stdout.Write(out)
+		if confErr != nil {
+			fmt.Fprintln(os.Stderr, confErr)
+			os.Exit(1)
+		}
+	},
+}
+
+var configExplainCmd = &cobra.Command{
+	Use:   "explain [key]",
+	Short: "Show which layer set a value and what it overrode",
+	Long: `Show which layer set a value and what it overrode.
+
+The layers are, from the lowest to the highest priority: defaults, system file
+(/etc/myprogram/conf.* or /config/conf.*), user file
+(~/.config/myprogram/conf.*), project file (./conf.* or --conf), environment
+variables (see config env) and flags. Without a key, the layer of every value
+is listed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, ls := currentConf()
+		if c == nil {
+			logrus.WithError(confErr).Fatal("Couldn't load configuration")
+		}
+		if len(args) == 0 {
+			for _, f := range config.Defaults.Fields() {
+				origins := config.Explain(ls, f.Key)
+				o := origins[len(origins)-1]
+				fmt.Printf("%s = %v (%s, %s)\n", f.Key, display(f, o.Value), o.Layer, o.Source)
+			}
+			return
+		}
+		f, ok := config.Defaults.Lookup(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown key %q\n", args[0])
+			os.Exit(1)
+		}
+		origins := config.Explain(ls, f.Key)
+		for i := len(origins) - 1; i >= 0; i-- {
+			o := origins[i]
+			if i == len(origins)-1 {
+				fmt.Printf("%s = %v\n  set by %s (%s)\n", f.Key, display(f, o.Value), o.Layer, o.Source)
+				continue
+			}
+			fmt.Printf("  overrides %v from %s (%s)\n", display(f, o.Value), o.Layer, o.Source)
+		}
+	},
+}
+
+var configEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List the recognized environment variables and their value",
+	Long: `List the recognized environment variables and their value.
+
+Every key can be set with its variable, the key being upper cased with dots and
+dashes replaced by underscores and prefixed by --env-prefix. The _FILE variant
+holds the path of a file containing the value, for Docker and Kubernetes
+secrets. Secrets are masked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VARIABLE\tKEY\tVALUE")
+		for _, v := range confLoader.EnvVars() {
+			val := "-"
+			if v.Set {
+				val = v.Value
+				if f, _ := config.Defaults.Lookup(v.Key); f.Secret && !v.File && val != "" {
+					val = config.Mask
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Key, val)
+		}
+		w.Flush()
+	},
+}
+
+// display formats a value of the field, masking it if it's a non-empty secret
+func display(f config.Field, v interface{}) string {
+	if f.Secret && v != nil && fmt.Sprint(v) != "" {
+		return config.Mask
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+func init() {
+	configDumpCmd.Flags().StringVar(&dumpFormat, "format", "yaml", "output format, one of yaml or json")
+	configCmd.AddCommand(configCheckCmd, configDumpCmd, configExplainCmd, configEnvCmd)
+}
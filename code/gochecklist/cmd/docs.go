@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var docsDir string
+
+var docsCmd = &cobra.Command{
+	Use:   "docs man|markdown",
+	Short: "Generate the man pages or markdown documentation",
+	Long: `Generate the man pages or markdown documentation of every command in the
+directory given by --dir. The build date is used as the date of the man pages
+so that they are reproducible.`,
+	ValidArgs: []string{"man", "markdown"},
+	Args:      cobra.ExactArgs(1),
+	// The documentation doesn't depend on the configuration
+	Annotations: map[string]string{noConfig: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		removePlugins()
+		if err := os.MkdirAll(docsDir, 0755); err != nil {
+			logrus.WithError(err).Fatal("Couldn't create documentation directory")
+		}
+		var err error
+		switch args[0] {
+		case "man":
+			h := &doc.GenManHeader{Title: "MYPROGRAM", Section: "1", Source: rootCmd.Name() + " " + info.Version}
+			if d, perr := time.Parse(time.RFC3339, info.Date); perr == nil {
+				h.Date = &d
+			}
+			err = doc.GenManTree(rootCmd, h, docsDir)
+		case "markdown":
+			err = doc.GenMarkdownTree(rootCmd, docsDir)
+		default:
+			err = fmt.Errorf("unknown format %q, expected man or markdown", args[0])
+		}
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't generate documentation")
+		}
+	},
+}
+
+func init() {
+	docsCmd.Flags().StringVar(&docsDir, "dir", "docs", "directory the documentation is written to")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Depado/articles/code/gochecklist/scaffold"
+)
+
+var (
+	newOpts   scaffold.Options
+	newDir    string
+	newDryRun bool
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new <module-path>",
+	Short: "Create a project following the checklist",
+	Long: `Create a project following the checklist: main.go with the version variables,
+cobra commands with viper configuration and logger setup, Makefile injecting
+the version, multi-stage Dockerfile, license and golangci-lint configuration.
+
+The optional components are toggled with their flag, --docker=false for
+example, and --dry-run lists the files without writing them.`,
+	Args: cobra.ExactArgs(1),
+	// The project doesn't depend on the configuration
+	Annotations: map[string]string{noConfig: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		o := newOpts
+		o.Module = args[0]
+		o.Name = scaffold.NameOf(o.Module)
+		o.Year = time.Now().Year()
+		if o.Author == "" {
+			o.Author = gitUser()
+		}
+		if o.Author == "" {
+			o.Author = "The " + o.Name + " authors"
+		}
+		dir := newDir
+		if dir == "" {
+			dir = o.Name
+		}
+
+		fs, err := scaffold.Render(o)
+		if err != nil {
+			logrus.WithError(err).Fatal("Couldn't render project")
+		}
+		if newDryRun {
+			for _, f := range fs {
+				fmt.Printf("%s (%d bytes)\n", filepath.Join(dir, filepath.FromSlash(f.Path)), len(f.Content))
+			}
+			return
+		}
+		if err := scaffold.Write(dir, fs); err != nil {
+			logrus.WithError(err).Fatal("Couldn't write project")
+		}
+		fmt.Printf("Created %s in %s, next steps:\n  cd %s && go mod tidy", o.Module, dir, dir)
+		if o.Makefile {
+			fmt.Print(" && make")
+		}
+		fmt.Println()
+		// The versions of the dependencies are resolved by go mod tidy
+		if o.Docker {
+			fmt.Println("go mod tidy writes the go.sum required by the lockfile check and the Dockerfile")
+		} else {
+			fmt.Println("go mod tidy writes the go.sum required by the lockfile check")
+		}
+	},
+}
+
+// gitUser returns the name configured in git, if any
+func gitUser() string {
+	out, err := exec.Command("git", "config", "user.name").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// goVersion returns the language version of the toolchain the program was
+// built with, 1.18 if it can't be determined
+func goVersion() string {
+	if m := regexp.MustCompile(`^go(1\.\d+)`).FindStringSubmatch(runtime.Version()); m != nil {
+		return m[1]
+	}
+	return "1.18"
+}
+
+func init() {
+	fs := newCmd.Flags()
+	fs.StringVar(&newDir, "dir", "", "directory of the project, the last element of the module path by default")
+	fs.BoolVar(&newDryRun, "dry-run", false, "list the files without writing them")
+	fs.StringVar(&newOpts.Author, "author", "", "copyright holder of the license, the git user by default")
+	fs.StringVar(&newOpts.License, "license", "mit", "one of "+strings.Join(scaffold.Licenses, ", "))
+	fs.StringVar(&newOpts.GoVersion, "go", goVersion(), "Go version of the module and Docker build image")
+	fs.BoolVar(&newOpts.Makefile, "makefile", true, "add a Makefile injecting the version")
+	fs.BoolVar(&newOpts.Docker, "docker", true, "add a multi-stage Dockerfile")
+	fs.BoolVar(&newOpts.Lint, "lint", true, "add a golangci-lint configuration")
+}
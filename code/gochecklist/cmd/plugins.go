@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Depado/articles/code/gochecklist/config"
+	"github.com/Depado/articles/code/gochecklist/plugins"
+)
+
+// pluginCmds are the commands added for the plugins
+var pluginCmds []*cobra.Command
+
+// parseGlobalFlags parses the global flags ahead of cobra so that the
+// configuration, and thus the plugins directory, is known before the command
+// is resolved. The errors are left for cobra to report.
+func parseGlobalFlags(args []string) {
+	fs := pflag.NewFlagSet(rootCmd.Name(), pflag.ContinueOnError)
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetOutput(ioutil.Discard)
+	fs.AddFlagSet(rootCmd.PersistentFlags())
+	fs.Parse(args)
+}
+
+// addPlugins adds a command for every plugin, except the ones named after a
+// builtin command
+func addPlugins() {
+	var dirs []string
+	if c, _ := currentConf(); c != nil && c.Plugins.Dir != "" {
+		dirs = append(dirs, c.Plugins.Dir)
+	}
+	builtin := map[string]bool{"help": true}
+	for _, c := range rootCmd.Commands() {
+		builtin[c.Name()] = true
+		for _, a := range c.Aliases {
+			builtin[a] = true
+		}
+	}
+	for _, p := range plugins.Discover(rootCmd.Name(), dirs...) {
+		if builtin[p.Name] {
+			logrus.WithField("plugin", p.Path).Debug("Plugin named after a builtin command, ignored")
+			continue
+		}
+		c := pluginCmd(p)
+		pluginCmds = append(pluginCmds, c)
+		rootCmd.AddCommand(c)
+	}
+}
+
+// removePlugins removes the plugin commands so that the generated artifacts
+// don't depend on the plugins installed where they are generated
+func removePlugins() {
+	rootCmd.RemoveCommand(pluginCmds...)
+}
+
+// pluginCmd returns the command running the plugin. Every argument following
+// the plugin name is passed through, flags included, and the absolute path of
+// the configuration file with the highest priority is given in the
+// environment.
+func pluginCmd(p plugins.Plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:                p.Name,
+		Short:              "Plugin " + p.Path,
+		DisableFlagParsing: true,
+		// The plugin deals with the configuration itself
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			var env []string
+			_, ls := currentConf()
+			if fs := config.Files(ls); len(fs) > 0 {
+				f, err := filepath.Abs(fs[len(fs)-1])
+				if err != nil {
+					f = fs[len(fs)-1]
+				}
+				env = append(env, confLoader.EnvName("conf")+"="+f)
+			}
+			if a, ok := pluginArgs(rootCmd.PersistentFlags(), os.Args[1:], p.Name); ok {
+				args = a
+			}
+			code, err := p.Run(args, env...)
+			if err != nil {
+				logrus.WithError(err).WithField("plugin", p.Path).Fatal("Couldn't run plugin")
+			}
+			os.Exit(code)
+		},
+	}
+}
+
+// pluginArgs returns the arguments following the plugin name in args, the
+// command line. The global flags given before the name were already parsed
+// by parseGlobalFlags and aren't forwarded to the plugin, which cobra doesn't
+// do since the flag parsing is disabled for the plugin commands.
+func pluginArgs(fs *pflag.FlagSet, args []string, name string) ([]string, bool) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == name:
+			return args[i+1:], true
+		case a == "--":
+			return nil, false
+		case strings.HasPrefix(a, "-") && !strings.Contains(a, "="):
+			// The value of a flag may be the next argument
+			var f *pflag.Flag
+			if n := strings.TrimLeft(a, "-"); strings.HasPrefix(a, "--") {
+				f = fs.Lookup(n)
+			} else if len(n) == 1 {
+				f = fs.ShorthandLookup(n)
+			}
+			if f != nil && f.NoOptDefVal == "" {
+				i++
+			}
+		}
+	}
+	return nil, false
+}
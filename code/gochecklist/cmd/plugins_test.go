@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPluginArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+		ok   bool
+	}{
+		{"no flag", []string{"hello", "y"}, []string{"y"}, true},
+		{"global flag with equal", []string{"--log.level=debug", "hello", "y"}, []string{"y"}, true},
+		{"global flag and value", []string{"--conf", "hello", "hello", "y"}, []string{"y"}, true},
+		{"boolean global flag", []string{"--watch", "hello", "--watch"}, []string{"--watch"}, true},
+		{"flags after the name", []string{"--log.level", "debug", "hello", "--log.level=info", "-x"}, []string{"--log.level=info", "-x"}, true},
+		{"unknown flag", []string{"--verbose", "hello"}, []string{}, true},
+		{"not found", []string{"--conf", "hello"}, nil, false},
+		{"after the terminator", []string{"--", "hello"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pluginArgs(rootCmd.PersistentFlags(), tt.args, "hello")
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q (%v), expected %q (%v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
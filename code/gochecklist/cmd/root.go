@@ -1,74 +1,155 @@
 package cmd
 
 import (
-	"github.com/onrik/logrus/filename"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"github.com/Depado/articles/code/gochecklist/buildinfo"
+	"github.com/Depado/articles/code/gochecklist/config"
+	"github.com/Depado/articles/code/gochecklist/logging"
+)
+
+// conf is the configuration loaded by confLoader on initialization,
+// confLayers the layers it was merged from and confErr the reason why it's
+// invalid, if it is. Once valid the configuration is held by confStore, which
+// is updated when a file is reloaded, so it must be read with currentConf.
+var (
+	confLoader *config.Loader
+	conf       *config.Config
+	confLayers []config.Layer
+	confErr    error
+	confStore  *config.Store
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "myprogram",
 	Short: "Myprogram does stuff I guess",
 	Long:  "Simple program that does stuff.",
+	// Keeps the generated documentation reproducible
+	DisableAutoGenTag: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if confErr != nil {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+		}
+		return confErr
+	},
 }
 
-// Execute executes the commands
-func Execute(b, v string) {
-	Build = b
-	Version = v
-	rootCmd.AddCommand(version)
+// noConfig is the annotation of the commands that don't use the
+// configuration, which isn't loaded at all when they are run
+const noConfig = "noconfig"
+
+// Execute executes the commands. The configuration is loaded before the
+// commands are resolved since it gives the plugins directory, unless the
+// command is a builtin one annotated with noConfig.
+func Execute(bi buildinfo.Info) {
+	info = bi
+	rootCmd.AddCommand(version, configCmd, serveCmd, completionCmd, docsCmd, newCmd, checkCmd)
+	parseGlobalFlags(os.Args[1:])
+	if c, _, err := rootCmd.Find(os.Args[1:]); err != nil || c.Annotations[noConfig] == "" {
+		initialize()
+	}
+	addPlugins()
 	if err := rootCmd.Execute(); err != nil {
 		logrus.WithError(err).Fatal()
 	}
 }
 
 func init() {
-	cobra.OnInitialize(initialize)
-
 	// Global flags
-	rootCmd.PersistentFlags().String("conf", "", "path to the configuration file")
-	rootCmd.PersistentFlags().String("log.level", "info", "one of debug, info, warn, error or fatal")
-	rootCmd.PersistentFlags().String("log.format", "text", "one of text or json")
-	rootCmd.PersistentFlags().Bool("log.line", false, "enable filename and line in logs")
+	rootCmd.PersistentFlags().String("conf", "", "path to the configuration file, replacing ./conf.{yaml,yml,toml,json}")
+	rootCmd.PersistentFlags().Bool("watch", false, "reload the configuration when one of its files changes")
+	rootCmd.PersistentFlags().String("env-prefix", strings.ToUpper(rootCmd.Name()), "prefix of the environment variables, empty for none")
+	addFlags(rootCmd.PersistentFlags())
 
 	// Flag binding
 	viper.BindPFlags(rootCmd.PersistentFlags())
 }
 
-func initialize() {
-	// Environment variables
-	viper.AutomaticEnv()
-
-	if viper.GetString("conf") != "" {
-		viper.SetConfigFile(viper.GetString("conf"))
-	} else {
-		viper.SetConfigName("conf")
-		viper.AddConfigPath(".")
-		viper.AddConfigPath("/config/")
+// addFlags adds a flag for every configuration field, using its default value
+// and description
+func addFlags(fs *pflag.FlagSet) {
+	for _, f := range config.Defaults.Fields() {
+		switch v := f.Value.(type) {
+		case string:
+			fs.String(f.Key, v, f.Description)
+		case int:
+			fs.Int(f.Key, v, f.Description)
+		case bool:
+			fs.Bool(f.Key, v, f.Description)
+		}
 	}
+}
 
-	// Configuration file
-	if err := viper.ReadInConfig(); err != nil {
+func initialize() {
+	confLoader = &config.Loader{
+		Name:      rootCmd.Name(),
+		File:      viper.GetString("conf"),
+		Flags:     rootCmd.PersistentFlags(),
+		EnvPrefix: viper.GetString("env-prefix"),
+	}
+	conf, confLayers, confErr = confLoader.Load()
+	if confLayers != nil && len(config.Files(confLayers)) == 0 {
 		logrus.Warn("No configuration file found")
 	}
+	if confErr != nil {
+		return
+	}
+	setupLogger(conf.Log)
+	for _, l := range confLayers {
+		if len(l.Unknown) > 0 {
+			logrus.WithFields(logrus.Fields{"file": l.Source, "keys": l.Unknown}).Warn("Unknown configuration keys")
+		}
+	}
 
-	lvl := viper.GetString("log.level")
-	l, err := logrus.ParseLevel(lvl)
-	if err != nil {
-		logrus.WithField("level", lvl).Warn("Invalid log level, fallback to 'info'")
-	} else {
-		logrus.SetLevel(l)
+	confStore = config.NewStore(confLoader, conf, confLayers)
+	confStore.Subscribe(func(old, new *config.Config) {
+		for _, c := range config.Diff(old, new) {
+			logrus.WithFields(logrus.Fields{"key": c.Key, "old": c.Old, "new": c.New}).Info("Configuration changed")
+		}
+		setupLogger(new.Log)
+	})
+	if viper.GetBool("watch") {
+		err := confStore.Watch(func(err error) {
+			logrus.WithError(err).Error("Configuration update rejected, keeping the previous one")
+		})
+		if err != nil {
+			logrus.WithError(err).Error("Couldn't watch configuration files")
+		}
+	}
+}
+
+// currentConf returns the current configuration and its layers, the ones of
+// confStore when the configuration is valid so that the reloads are seen
+func currentConf() (*config.Config, []config.Layer) {
+	if confStore != nil {
+		return confStore.Current()
 	}
-	switch viper.GetString("log.format") {
-	case "json":
-		logrus.SetFormatter(&logrus.JSONFormatter{})
-	default:
-	case "text":
-		logrus.SetFormatter(&logrus.TextFormatter{})
+	return conf, confLayers
+}
+
+// logOutput is the output of the logger, closed when replaced
+var logOutput io.Closer
+
+// setupLogger configures logrus, the configuration being already validated.
+// It's called again when the configuration is reloaded, in which case the
+// previous output is closed once replaced.
+func setupLogger(c config.Log) {
+	out, err := logging.Setup(c, logging.Static{Service: rootCmd.Name(), Version: info.Version, Build: info.Build})
+	if err != nil {
+		logrus.WithError(err).Error("Couldn't configure logger")
+		return
 	}
-	if viper.GetBool("log.line") {
-		logrus.AddHook(filename.NewHook())
+	if logOutput != nil {
+		logOutput.Close()
 	}
+	logOutput = out
 }
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start the HTTP server",
+	Long: `Start the HTTP server on server.host and server.port.
+
+  GET /version  build information as JSON, see version --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _ := currentConf()
+		mux := http.NewServeMux()
+		mux.Handle("/version", info.Handler())
+
+		// Changing the address requires a restart, the listener isn't
+		// replaced when the configuration is reloaded
+		addr := net.JoinHostPort(c.Server.Host, strconv.Itoa(c.Server.Port))
+		logrus.WithField("addr", addr).Info("Starting server")
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logrus.WithError(err).Fatal("Couldn't start server")
+		}
+	},
+}
@@ -1,21 +1,55 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+
+	"github.com/Depado/articles/code/gochecklist/buildinfo"
 )
 
-// Build number and versions injected at compile time
+// info is the build information given to Execute
+var info buildinfo.Info
+
 var (
-	Build   string
-	Version string
+	versionJSON  bool
+	versionShort bool
 )
 
 var version = &cobra.Command{
 	Use:   "version",
 	Short: "Show build and version",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("Build: %s\nVersion: %s\n", Build, Version)
+		switch {
+		case versionShort:
+			fmt.Println(info.Version)
+		case versionJSON:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(info); err != nil {
+				logrus.WithError(err).Fatal("Couldn't encode build information")
+			}
+		default:
+			fmt.Printf("Build: %s\nVersion: %s\n", info.Build, info.Version)
+			if info.Date != "" {
+				fmt.Printf("Date: %s\n", info.Date)
+			}
+			fmt.Printf("Go: %s\n", info.GoVersion)
+			if info.Revision != "" {
+				dirty := ""
+				if info.Dirty {
+					dirty = " (dirty)"
+				}
+				fmt.Printf("Revision: %s%s\n", info.Revision, dirty)
+			}
+		}
 	},
 }
+
+func init() {
+	version.Flags().BoolVar(&versionJSON, "json", false, "output the complete build information as JSON, dependencies included")
+	version.Flags().BoolVar(&versionShort, "short", false, "only output the version")
+}
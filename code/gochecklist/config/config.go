@@ -0,0 +1,129 @@
+// Package config holds the typed configuration of the program, along with its
+// defaults and validation.
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Mask replaces the value of the secrets when the configuration is displayed
+const Mask = "********"
+
+// Log is the logger configuration
+type Log struct {
+	Level  string `mapstructure:"level" desc:"one of debug, info, warn, error or fatal"`
+	Format string `mapstructure:"format" desc:"one of text, logfmt, json, gelf or ecs"`
+	Line   bool   `mapstructure:"line" desc:"enable filename and line in logs"`
+	Output string `mapstructure:"output" desc:"stdout, stderr or the path of a log file"`
+	Stderr bool   `mapstructure:"stderr" desc:"write the error, fatal and panic entries to stderr when the output is stdout"`
+	Static bool   `mapstructure:"static" desc:"add the service, version and build fields to every entry"`
+	Rotate Rotate `mapstructure:"rotate"`
+}
+
+// Rotate is the rotation of the log file
+type Rotate struct {
+	MaxSize    int  `mapstructure:"max-size" desc:"size in megabytes of the log file before it's rotated"`
+	MaxAge     int  `mapstructure:"max-age" desc:"number of days the rotated files are kept, 0 to keep them forever"`
+	MaxBackups int  `mapstructure:"max-backups" desc:"number of rotated files kept, 0 to keep them all"`
+	Compress   bool `mapstructure:"compress" desc:"compress the rotated files with gzip"`
+}
+
+// Server is the configuration of the HTTP server
+type Server struct {
+	Host  string `mapstructure:"host" desc:"host the server listens on"`
+	Port  int    `mapstructure:"port" desc:"port the server listens on"`
+	Token string `mapstructure:"token" desc:"token required to call the API" secret:"true"`
+}
+
+// Plugins is the configuration of the external commands
+type Plugins struct {
+	Dir string `mapstructure:"dir" desc:"directory searched for myprogram-<name> plugins before the PATH"`
+}
+
+// Config is the complete configuration of the program. Every field has a
+// mapstructure tag giving its key, a desc tag used as the flag usage and
+// optionally a secret tag so that its value is masked when displayed.
+type Config struct {
+	Log     Log     `mapstructure:"log"`
+	Server  Server  `mapstructure:"server"`
+	Plugins Plugins `mapstructure:"plugins"`
+}
+
+// Defaults is the default configuration, the only place where defaults are
+// declared
+var Defaults = Config{
+	Log: Log{
+		Level:  "info",
+		Format: "text",
+		Output: "stdout",
+		Static: true,
+		Rotate: Rotate{MaxSize: 100},
+	},
+	Server: Server{
+		Host: "127.0.0.1",
+		Port: 8080,
+	},
+}
+
+// Field is a leaf of the configuration
+type Field struct {
+	Key         string
+	Value       interface{}
+	Description string
+	Secret      bool
+}
+
+// Display returns the value of the field, masked if it's a non-empty secret
+func (f Field) Display() interface{} {
+	if f.Secret && !reflect.ValueOf(f.Value).IsZero() {
+		return Mask
+	}
+	return f.Value
+}
+
+// Fields returns the leaves of the configuration sorted by key
+func (c Config) Fields() []Field {
+	var fs []Field
+	walk(reflect.ValueOf(c), "", &fs)
+	sort.Slice(fs, func(i, j int) bool { return fs[i].Key < fs[j].Key })
+	return fs
+}
+
+func walk(v reflect.Value, prefix string, fs *[]Field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := prefix + sf.Tag.Get("mapstructure")
+		if sf.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), key+".", fs)
+			continue
+		}
+		*fs = append(*fs, Field{
+			Key:         key,
+			Value:       v.Field(i).Interface(),
+			Description: sf.Tag.Get("desc"),
+			Secret:      sf.Tag.Get("secret") == "true",
+		})
+	}
+}
+
+// Masked returns the configuration as nested maps, the secrets being masked
+func (c Config) Masked() map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, f := range c.Fields() {
+		parts := strings.Split(f.Key, ".")
+		cur := m
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := cur[p].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				cur[p] = next
+			}
+			cur = next
+		}
+		cur[parts[len(parts)-1]] = f.Display()
+	}
+	return m
+}
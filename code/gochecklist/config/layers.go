@@ -0,0 +1,382 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Names of the layers, from the lowest to the highest priority
+const (
+	DefaultsLayer = "defaults"
+	SystemLayer   = "system"
+	UserLayer     = "user"
+	ProjectLayer  = "project"
+	EnvLayer      = "env"
+	FlagsLayer    = "flags"
+)
+
+// Extensions are the supported configuration file formats, in the order they
+// are looked up
+var Extensions = []string{"yaml", "yml", "toml", "json"}
+
+// fileName is the name of the configuration files without their extension
+const fileName = "conf"
+
+// Layer is a source of configuration values, overriding the values of the
+// previous layers
+type Layer struct {
+	Name string
+	// Source describes where the values come from, the path of the file for
+	// the file layers
+	Source string
+	// Values holds the values set by the layer, by key
+	Values map[string]interface{}
+	// Sources holds the source of the values when it depends on the key, the
+	// environment variable or flag for example
+	Sources map[string]string
+	// Unknown lists the keys found in the file that aren't part of Config
+	Unknown []string
+}
+
+// Origin is a value set by a layer
+type Origin struct {
+	Layer  string
+	Source string
+	Value  interface{}
+}
+
+// Loader reads the configuration layers, which are from the lowest to the
+// highest priority: defaults, system file, user file, project file,
+// environment and flags.
+type Loader struct {
+	// Name is the name of the program, used to find the system and user files
+	Name string
+	// File replaces the project file when set, it must exist
+	File string
+	// Flags are the command line flags, only the changed ones being used
+	Flags *pflag.FlagSet
+	// EnvPrefix is prepended to the environment variables, followed by an
+	// underscore, no prefix being used if empty
+	EnvPrefix string
+	// LookupEnv looks up the environment variables, os.LookupEnv if nil
+	LookupEnv func(string) (string, bool)
+}
+
+// SystemDirs are the directories searched for the system file, the first one
+// holding a file being used
+func (l *Loader) SystemDirs() []string {
+	return []string{filepath.Join("/etc", l.Name), "/config"}
+}
+
+// UserDir is the directory of the user file
+func (l *Loader) UserDir() string {
+	d, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(d, l.Name)
+}
+
+// Candidates returns the paths a configuration file may be read from, by
+// layer, whether they exist or not
+func (l *Loader) Candidates() map[string][]string {
+	cs := map[string][]string{}
+	for _, d := range l.SystemDirs() {
+		cs[SystemLayer] = append(cs[SystemLayer], candidates(d)...)
+	}
+	if d := l.UserDir(); d != "" {
+		cs[UserLayer] = candidates(d)
+	}
+	if l.File != "" {
+		cs[ProjectLayer] = []string{l.File}
+	} else {
+		cs[ProjectLayer] = candidates(".")
+	}
+	return cs
+}
+
+func candidates(dir string) []string {
+	ps := make([]string, 0, len(Extensions))
+	for _, e := range Extensions {
+		ps = append(ps, filepath.Join(dir, fileName+"."+e))
+	}
+	return ps
+}
+
+// Layers reads every layer. The file layers are omitted when no file is
+// found, except for an explicit File which must exist.
+func (l *Loader) Layers() ([]Layer, error) {
+	ls := []Layer{defaultsLayer()}
+	cs := l.Candidates()
+	for _, name := range []string{SystemLayer, UserLayer, ProjectLayer} {
+		p := firstExisting(cs[name])
+		if p == "" {
+			if name == ProjectLayer && l.File != "" {
+				return nil, fmt.Errorf("configuration file %s not found", l.File)
+			}
+			continue
+		}
+		fl, err := fileLayer(name, p)
+		if err != nil {
+			return nil, err
+		}
+		ls = append(ls, fl)
+	}
+	el, err := l.envLayer()
+	if err != nil {
+		return nil, err
+	}
+	ls = append(ls, el, l.flagsLayer())
+	return ls, nil
+}
+
+// Files returns the paths of the files read by the given layers
+func Files(ls []Layer) []string {
+	var fs []string
+	for _, l := range ls {
+		switch l.Name {
+		case SystemLayer, UserLayer, ProjectLayer:
+			fs = append(fs, l.Source)
+		}
+	}
+	return fs
+}
+
+func firstExisting(ps []string) string {
+	for _, p := range ps {
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+func defaultsLayer() Layer {
+	l := Layer{Name: DefaultsLayer, Source: "built-in", Values: map[string]interface{}{}}
+	for _, f := range Defaults.Fields() {
+		l.Values[f.Key] = f.Value
+	}
+	return l
+}
+
+// fileLayer reads a configuration file, its format depending on its extension
+func fileLayer(name, p string) (Layer, error) {
+	l := Layer{Name: name, Source: p, Values: map[string]interface{}{}}
+	v := viper.New()
+	v.SetConfigFile(p)
+	if err := v.ReadInConfig(); err != nil {
+		return l, fmt.Errorf("couldn't read %s: %v", p, err)
+	}
+	known := keys()
+	for _, k := range v.AllKeys() {
+		if !known[k] {
+			l.Unknown = append(l.Unknown, k)
+			continue
+		}
+		l.Values[k] = v.Get(k)
+	}
+	sort.Strings(l.Unknown)
+	return l, nil
+}
+
+// envReplacer maps the keys to the environment variables
+var envReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// fileSuffix is the suffix of the variables holding the path of a file
+// containing the value, Docker and Kubernetes secrets for example
+const fileSuffix = "_FILE"
+
+// EnvName returns the environment variable of a key, log.rotate.max-size
+// being MYPROGRAM_LOG_ROTATE_MAX_SIZE with the MYPROGRAM prefix
+func (l *Loader) EnvName(key string) string {
+	n := strings.ToUpper(envReplacer.Replace(key))
+	if l.EnvPrefix != "" {
+		n = l.EnvPrefix + "_" + n
+	}
+	return n
+}
+
+func (l *Loader) lookupEnv(name string) (string, bool) {
+	if l.LookupEnv != nil {
+		return l.LookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// EnvVar is an environment variable recognized by the loader
+type EnvVar struct {
+	Name string
+	Key  string
+	// File is true for the variables holding the path of a file containing
+	// the value
+	File  bool
+	Value string
+	Set   bool
+}
+
+// EnvVars returns the variables of every key along with their file variant
+func (l *Loader) EnvVars() []EnvVar {
+	var vs []EnvVar
+	for _, f := range Defaults.Fields() {
+		n := l.EnvName(f.Key)
+		for _, file := range []bool{false, true} {
+			v := EnvVar{Name: n, Key: f.Key, File: file}
+			if file {
+				v.Name += fileSuffix
+			}
+			v.Value, v.Set = l.lookupEnv(v.Name)
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// envLayer reads the variable of every key or, if unset, the file given by
+// its file variant. Setting both is an error.
+func (l *Loader) envLayer() (Layer, error) {
+	ly := Layer{Name: EnvLayer, Source: "environment", Values: map[string]interface{}{}, Sources: map[string]string{}}
+	for _, f := range Defaults.Fields() {
+		n := l.EnvName(f.Key)
+		v, ok := l.lookupEnv(n)
+		p, fok := l.lookupEnv(n + fileSuffix)
+		switch {
+		case ok && fok:
+			return ly, fmt.Errorf("both %s and %s%s are set", n, n, fileSuffix)
+		case ok:
+			ly.Values[f.Key] = v
+			ly.Sources[f.Key] = n
+		case fok:
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				return ly, fmt.Errorf("couldn't read %s%s: %v", n, fileSuffix, err)
+			}
+			ly.Values[f.Key] = strings.TrimRight(string(b), "\r\n")
+			ly.Sources[f.Key] = n + fileSuffix + " " + p
+		}
+	}
+	return ly, nil
+}
+
+func (l *Loader) flagsLayer() Layer {
+	ly := Layer{Name: FlagsLayer, Source: "command line", Values: map[string]interface{}{}, Sources: map[string]string{}}
+	if l.Flags == nil {
+		return ly
+	}
+	for _, f := range Defaults.Fields() {
+		fl := l.Flags.Lookup(f.Key)
+		if fl == nil || !fl.Changed {
+			continue
+		}
+		var v interface{}
+		var err error
+		switch fl.Value.Type() {
+		case "int":
+			v, err = l.Flags.GetInt(f.Key)
+		case "bool":
+			v, err = l.Flags.GetBool(f.Key)
+		default:
+			v = fl.Value.String()
+		}
+		if err == nil {
+			ly.Values[f.Key] = v
+			ly.Sources[f.Key] = "--" + f.Key
+		}
+	}
+	return ly
+}
+
+// keys returns the set of the known keys
+func keys() map[string]bool {
+	ks := make(map[string]bool)
+	for _, f := range Defaults.Fields() {
+		ks[f.Key] = true
+	}
+	return ks
+}
+
+// Explain returns the origin of every value set for the key, from the lowest
+// to the highest priority layer, the last one being the effective value
+func Explain(ls []Layer, key string) []Origin {
+	var origins []Origin
+	for _, l := range ls {
+		v, ok := l.Values[key]
+		if !ok {
+			continue
+		}
+		o := Origin{Layer: l.Name, Source: l.Source, Value: v}
+		if s, ok := l.Sources[key]; ok {
+			o.Source = s
+		}
+		origins = append(origins, o)
+	}
+	return origins
+}
+
+// Lookup returns the field of the given key from the configuration
+func (c Config) Lookup(key string) (Field, bool) {
+	for _, f := range c.Fields() {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Merge computes the configuration resulting from the layers and validates
+// it. A value that can't be decoded, a port set to "abc" for example, is
+// reported along with the invalid fields, the default being kept. The
+// configuration is returned even if invalid so that it can be displayed.
+func Merge(ls []Layer) (*Config, error) {
+	var errs Errors
+	v := viper.New()
+	for _, f := range Defaults.Fields() {
+		origins := Explain(ls, f.Key)
+		if len(origins) == 0 {
+			continue
+		}
+		o := origins[len(origins)-1]
+		if err := decode(f.Key, o.Value); err != nil {
+			errs = append(errs, &FieldError{Key: f.Key, Msg: fmt.Sprintf("%q set by %s isn't a valid %T", fmt.Sprint(o.Value), o.Source, f.Value)})
+			continue
+		}
+		v.Set(f.Key, o.Value)
+	}
+	c := Defaults
+	if err := v.Unmarshal(&c); err != nil {
+		return nil, fmt.Errorf("couldn't decode configuration: %v", err)
+	}
+	var verrs Errors
+	if errors.As(c.Validate(), &verrs) {
+		errs = append(errs, verrs...)
+	}
+	if len(errs) > 0 {
+		return &c, errs
+	}
+	return &c, nil
+}
+
+// decode returns the error decoding the value of a single key
+func decode(key string, val interface{}) error {
+	v := viper.New()
+	v.Set(key, val)
+	c := Defaults
+	return v.Unmarshal(&c)
+}
+
+// Load reads the layers and merges them
+func (l *Loader) Load() (*Config, []Layer, error) {
+	ls, err := l.Layers()
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := Merge(ls)
+	return c, ls, err
+}
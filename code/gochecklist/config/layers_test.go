@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestExplain(t *testing.T) {
+	ls := []Layer{
+		{Name: DefaultsLayer, Source: "built-in", Values: map[string]interface{}{"server.port": 8080, "log.level": "info"}},
+		{Name: ProjectLayer, Source: "conf.yaml", Values: map[string]interface{}{"server.port": 9090}},
+		{Name: EnvLayer, Source: "environment", Values: map[string]interface{}{"server.port": "9191"}, Sources: map[string]string{"server.port": "MYPROGRAM_SERVER_PORT"}},
+		{Name: FlagsLayer, Source: "command line", Values: map[string]interface{}{}, Sources: map[string]string{}},
+	}
+	tests := []struct {
+		key  string
+		want []Origin
+	}{
+		{"server.port", []Origin{
+			{Layer: DefaultsLayer, Source: "built-in", Value: 8080},
+			{Layer: ProjectLayer, Source: "conf.yaml", Value: 9090},
+			{Layer: EnvLayer, Source: "MYPROGRAM_SERVER_PORT", Value: "9191"},
+		}},
+		{"log.level", []Origin{
+			{Layer: DefaultsLayer, Source: "built-in", Value: "info"},
+		}},
+		{"unknown", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := Explain(ls, tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, expected %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoaderFormats(t *testing.T) {
+	files := map[string]string{
+		"yaml": "log:\n  level: debug\nserver:\n  port: 9090\n",
+		"toml": "[log]\nlevel = \"debug\"\n[server]\nport = 9090\n",
+		"json": `{"log": {"level": "debug"}, "server": {"port": 9090}}`,
+	}
+	for ext, content := range files {
+		t.Run(ext, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "conf."+ext)
+			if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			l := &Loader{
+				Name:      "myprogram-test",
+				File:      p,
+				LookupEnv: func(string) (string, bool) { return "", false },
+			}
+			c, ls, err := l.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Log.Level != "debug" || c.Server.Port != 9090 {
+				t.Errorf("got level %q and port %d, expected debug and 9090", c.Log.Level, c.Server.Port)
+			}
+			origins := Explain(ls, "server.port")
+			if last := origins[len(origins)-1]; last.Layer != ProjectLayer || last.Source != p {
+				t.Errorf("server.port set by %s (%s), expected %s (%s)", last.Layer, last.Source, ProjectLayer, p)
+			}
+		})
+	}
+}
+
+func TestLoaderPriority(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := os.WriteFile(p, []byte("server:\n  port: 9090\n  host: 0.0.0.0\nlog:\n  format: json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"MYPROGRAM_SERVER_PORT": "9191"}
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("log.format", "text", "")
+	if err := fs.Parse([]string{"--log.format", "ecs"}); err != nil {
+		t.Fatal(err)
+	}
+	l := &Loader{
+		Name:      "myprogram-test",
+		File:      p,
+		Flags:     fs,
+		EnvPrefix: "MYPROGRAM",
+		LookupEnv: func(n string) (string, bool) {
+			v, ok := env[n]
+			return v, ok
+		},
+	}
+	c, ls, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		layer  string
+		source string
+		layers int
+	}{
+		{"server.port", EnvLayer, "MYPROGRAM_SERVER_PORT", 3},
+		{"server.host", ProjectLayer, p, 2},
+		{"log.format", FlagsLayer, "--log.format", 3},
+		{"log.level", DefaultsLayer, "built-in", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			origins := Explain(ls, tt.key)
+			if len(origins) != tt.layers {
+				t.Fatalf("got %d origins, expected %d: %+v", len(origins), tt.layers, origins)
+			}
+			if last := origins[len(origins)-1]; last.Layer != tt.layer || last.Source != tt.source {
+				t.Errorf("set by %s (%s), expected %s (%s)", last.Layer, last.Source, tt.layer, tt.source)
+			}
+		})
+	}
+	if c.Server.Port != 9191 || c.Server.Host != "0.0.0.0" || c.Log.Format != "ecs" {
+		t.Errorf("unexpected configuration %+v", c)
+	}
+}
+
+func TestLoaderDecodeErrors(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := os.WriteFile(p, []byte("log:\n  level: loud\n  rotate:\n    max-size: big\nserver:\n  host: 0.0.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"MYPROGRAM_SERVER_PORT": "abc", "MYPROGRAM_LOG_LINE": "maybe"}
+	l := &Loader{
+		Name:      "myprogram-test",
+		File:      p,
+		EnvPrefix: "MYPROGRAM",
+		LookupEnv: func(n string) (string, bool) {
+			v, ok := env[n]
+			return v, ok
+		},
+	}
+	c, _, err := l.Load()
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %T: %v", err, err)
+	}
+	want := []string{
+		`log.line: "maybe" set by MYPROGRAM_LOG_LINE isn't a valid bool`,
+		`log.rotate.max-size: "big" set by ` + p + ` isn't a valid int`,
+		`server.port: "abc" set by MYPROGRAM_SERVER_PORT isn't a valid int`,
+		`log.level: "loud" isn't one of debug, info, warn, error, fatal`,
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got errors\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The values that could be decoded are kept, the defaults otherwise
+	if c == nil || c.Server.Host != "0.0.0.0" || c.Server.Port != Defaults.Server.Port || c.Log.Rotate.MaxSize != Defaults.Log.Rotate.MaxSize {
+		t.Errorf("unexpected configuration %+v", c)
+	}
+}
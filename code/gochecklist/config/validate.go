@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Levels are the accepted log levels
+var Levels = []string{"debug", "info", "warn", "error", "fatal"}
+
+// Formats are the accepted log formats
+var Formats = []string{"text", "logfmt", "json", "gelf", "ecs"}
+
+// Errors is the aggregated report of every invalid field
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return "invalid configuration: " + e[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration, %d errors:", len(e))
+	for _, err := range e {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// FieldError is the error of a single field
+type FieldError struct {
+	Key string
+	Msg string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Msg
+}
+
+// validator gathers the errors of the fields
+type validator struct {
+	errs Errors
+}
+
+func (v *validator) add(key, format string, args ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Key: key, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) oneOf(key, val string, allowed []string) {
+	for _, a := range allowed {
+		if val == a {
+			return
+		}
+	}
+	v.add(key, "%q isn't one of %s", val, strings.Join(allowed, ", "))
+}
+
+func (v *validator) required(key, val string) {
+	if val == "" {
+		v.add(key, "is required")
+	}
+}
+
+func (v *validator) between(key string, val, min, max int) {
+	if val < min || val > max {
+		v.add(key, "%d isn't between %d and %d", val, min, max)
+	}
+}
+
+func (v *validator) atLeast(key string, val, min int) {
+	if val < min {
+		v.add(key, "%d is lower than %d", val, min)
+	}
+}
+
+// Validate checks every field and returns an Errors listing all the invalid
+// ones, or nil
+func (c Config) Validate() error {
+	v := &validator{}
+	v.oneOf("log.level", c.Log.Level, Levels)
+	v.oneOf("log.format", c.Log.Format, Formats)
+	v.required("log.output", c.Log.Output)
+	if c.Log.Stderr && c.Log.Output != "stdout" {
+		v.add("log.stderr", "only applies when log.output is stdout")
+	}
+	v.atLeast("log.rotate.max-size", c.Log.Rotate.MaxSize, 1)
+	v.atLeast("log.rotate.max-age", c.Log.Rotate.MaxAge, 0)
+	v.atLeast("log.rotate.max-backups", c.Log.Rotate.MaxBackups, 0)
+	v.required("server.host", c.Server.Host)
+	v.between("server.port", c.Server.Port, 1, 65535)
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
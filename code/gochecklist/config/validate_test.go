@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		keys   []string
+	}{
+		{"defaults", func(c *Config) {}, nil},
+		{"level", func(c *Config) { c.Log.Level = "verbose" }, []string{"log.level"}},
+		{"format", func(c *Config) { c.Log.Format = "xml" }, []string{"log.format"}},
+		{"output", func(c *Config) { c.Log.Output = "" }, []string{"log.output"}},
+		{"stderr with file output", func(c *Config) {
+			c.Log.Output = "/var/log/myprogram.log"
+			c.Log.Stderr = true
+		}, []string{"log.stderr"}},
+		{"stderr with stdout", func(c *Config) { c.Log.Stderr = true }, nil},
+		{"rotate", func(c *Config) {
+			c.Log.Rotate = Rotate{MaxSize: 0, MaxAge: -1, MaxBackups: -1}
+		}, []string{"log.rotate.max-size", "log.rotate.max-age", "log.rotate.max-backups"}},
+		{"host", func(c *Config) { c.Server.Host = "" }, []string{"server.host"}},
+		{"port zero", func(c *Config) { c.Server.Port = 0 }, []string{"server.port"}},
+		{"port too high", func(c *Config) { c.Server.Port = 65536 }, []string{"server.port"}},
+		{"port bounds", func(c *Config) { c.Server.Port = 65535 }, nil},
+		{"aggregated", func(c *Config) {
+			c.Log.Level = ""
+			c.Log.Format = "yaml"
+			c.Server.Port = -1
+		}, []string{"log.level", "log.format", "server.port"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Defaults
+			tt.modify(&c)
+			err := c.Validate()
+			if tt.keys == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected Errors, got %T: %v", err, err)
+			}
+			var keys []string
+			for _, e := range errs {
+				var fe *FieldError
+				if !errors.As(e, &fe) {
+					t.Fatalf("expected a FieldError, got %T: %v", e, e)
+				}
+				keys = append(keys, fe.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("invalid keys %v, expected %v", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	one := Errors{&FieldError{Key: "log.level", Msg: "is required"}}
+	if got, want := one.Error(), "invalid configuration: log.level: is required"; got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+
+	two := Errors{
+		&FieldError{Key: "log.level", Msg: "is required"},
+		&FieldError{Key: "server.port", Msg: "0 isn't between 1 and 65535"},
+	}
+	want := strings.Join([]string{
+		"invalid configuration, 2 errors:",
+		"  - log.level: is required",
+		"  - server.port: 0 isn't between 1 and 65535",
+	}, "\n")
+	if got := two.Error(); got != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Subscriber is notified when the configuration changes
+type Subscriber func(old, new *Config)
+
+// Change is the change of a single field, secrets being masked
+type Change struct {
+	Key string
+	Old interface{}
+	New interface{}
+}
+
+// Diff returns the fields that differ between old and new
+func Diff(old, new *Config) []Change {
+	var cs []Change
+	nfs := new.Fields()
+	for i, of := range old.Fields() {
+		if !reflect.DeepEqual(of.Value, nfs[i].Value) {
+			cs = append(cs, Change{Key: of.Key, Old: of.Display(), New: nfs[i].Display()})
+		}
+	}
+	return cs
+}
+
+// Store holds the last valid configuration and notifies its subscribers when
+// it's reloaded
+type Store struct {
+	l *Loader
+	// reload serializes the reloads so that the subscribers are notified of
+	// the changes in the order they were loaded
+	reload sync.Mutex
+
+	mu     sync.RWMutex
+	cur    *Config
+	layers []Layer
+	subs   []Subscriber
+}
+
+// NewStore returns a Store reloading the configuration with l, c being the
+// current valid configuration and ls the layers it was merged from
+func NewStore(l *Loader, c *Config, ls []Layer) *Store {
+	return &Store{l: l, cur: c, layers: ls}
+}
+
+// Get returns the current configuration
+func (s *Store) Get() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cur
+}
+
+// Layers returns the layers of the current configuration
+func (s *Store) Layers() []Layer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.layers
+}
+
+// Current returns the current configuration along with its layers
+func (s *Store) Current() (*Config, []Layer) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cur, s.layers
+}
+
+// Subscribe registers fn to be called after every successful reload that
+// changed the configuration
+func (s *Store) Subscribe(fn Subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, fn)
+}
+
+// Reload reads every layer and validates the configuration again. An invalid
+// configuration is rejected and the last valid one is kept. Concurrent reloads
+// are serialized, the subscribers being called before the next one starts.
+func (s *Store) Reload() error {
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	c, ls, err := s.l.Load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.cur
+	s.cur = c
+	s.layers = ls
+	subs := make([]Subscriber, len(s.subs))
+	copy(subs, s.subs)
+	s.mu.Unlock()
+
+	if len(Diff(old, c)) == 0 {
+		return nil
+	}
+	for _, fn := range subs {
+		fn(old, c)
+	}
+	return nil
+}
+
+// settle is the time waited after the last event before reloading the file,
+// since a single write usually produces several events and the first ones may
+// see a truncated file
+const settle = 100 * time.Millisecond
+
+// Watch reloads the configuration whenever one of its files is written,
+// created or removed, onError being called with the reason why an update was
+// rejected. The directories are watched rather than the files so that a file
+// created after startup is picked up too.
+func (s *Store) Watch(onError func(error)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, ps := range s.l.Candidates() {
+		for _, p := range ps {
+			p = filepath.Clean(p)
+			files[p] = true
+			dirs[filepath.Dir(p)] = true
+		}
+	}
+	for d := range dirs {
+		if fi, err := os.Stat(d); err != nil || !fi.IsDir() {
+			continue
+		}
+		if err := w.Add(d); err != nil {
+			w.Close()
+			return err
+		}
+	}
+
+	var mu sync.Mutex
+	var t *time.Timer
+	reload := func() {
+		if err := s.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+	go func() {
+		for {
+			select {
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if !files[filepath.Clean(e.Name)] || e.Op == fsnotify.Chmod {
+					continue
+				}
+				mu.Lock()
+				if t != nil {
+					t.Stop()
+				}
+				t = time.AfterFunc(settle, reload)
+				mu.Unlock()
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				if onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
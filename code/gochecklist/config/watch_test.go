@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := Defaults
+	new := Defaults
+	new.Server.Port = 9090
+	new.Server.Token = "secret"
+
+	want := []Change{
+		{Key: "server.port", Old: 8080, New: 9090},
+		{Key: "server.token", Old: "", New: Mask},
+	}
+	if got := Diff(&old, &new); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+	if got := Diff(&old, &old); got != nil {
+		t.Errorf("expected no change, got %+v", got)
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "conf.yaml")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(p, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("server:\n  port: 8081\n")
+
+	l := &Loader{
+		Name:      "myprogram-test",
+		File:      p,
+		LookupEnv: func(string) (string, bool) { return "", false },
+	}
+	c, ls, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(l, c, ls)
+
+	var changes [][2]int
+	s.Subscribe(func(old, new *Config) {
+		changes = append(changes, [2]int{old.Server.Port, new.Server.Port})
+	})
+
+	// An invalid configuration is rejected and the previous one kept
+	write("server:\n  port: 0\n")
+	if err := s.Reload(); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := s.Get().Server.Port; got != 8081 {
+		t.Errorf("got port %d, expected 8081 to be kept", got)
+	}
+
+	// An unchanged configuration isn't notified
+	write("server:\n  port: 8081\n")
+	if err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	write("server:\n  port: 8082\n")
+	if err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	c, ls = s.Current()
+	if c.Server.Port != 8082 {
+		t.Errorf("got port %d, expected 8082", c.Server.Port)
+	}
+	if fs := Files(ls); !reflect.DeepEqual(fs, []string{p}) {
+		t.Errorf("got files %v, expected %v", fs, []string{p})
+	}
+	if want := [][2]int{{8081, 8082}}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, expected %v", changes, want)
+	}
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GELFFormatter formats the entries as GELF 1.1 JSON messages, the fields
+// being sent as additional fields prefixed with an underscore
+type GELFFormatter struct {
+	Host string
+}
+
+func newGELFFormatter() *GELFFormatter {
+	h, err := os.Hostname()
+	if err != nil {
+		h = "localhost"
+	}
+	return &GELFFormatter{Host: h}
+}
+
+// syslog severities of the logrus levels
+var syslog = map[logrus.Level]int{
+	logrus.PanicLevel: 0,
+	logrus.FatalLevel: 2,
+	logrus.ErrorLevel: 3,
+	logrus.WarnLevel:  4,
+	logrus.InfoLevel:  6,
+	logrus.DebugLevel: 7,
+	logrus.TraceLevel: 7,
+}
+
+// Format implements logrus.Formatter
+func (f *GELFFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          f.Host,
+		"short_message": e.Message,
+		"timestamp":     float64(e.Time.UnixNano()) / float64(time.Second),
+		"level":         syslog[e.Level],
+	}
+	for k, v := range e.Data {
+		if k == "id" {
+			k = "field_id"
+		}
+		m["_"+k] = value(v)
+	}
+	if e.HasCaller() {
+		m["_file"] = e.Caller.File
+		m["_line"] = e.Caller.Line
+	}
+	return marshal(m)
+}
+
+// ECSFormatter formats the entries as Elastic Common Schema JSON documents.
+// The static fields, errors and caller are mapped to their ECS fields and the
+// other fields are kept as is.
+type ECSFormatter struct{}
+
+// ecsFields maps the fields set by the program to their ECS name
+var ecsFields = map[string]string{
+	"service":       "service.name",
+	"version":       "service.version",
+	"build":         "labels.build",
+	logrus.ErrorKey: "error.message",
+}
+
+// Format implements logrus.Formatter
+func (f *ECSFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	m := map[string]interface{}{
+		"@timestamp":  e.Time.UTC().Format(time.RFC3339Nano),
+		"log.level":   e.Level.String(),
+		"message":     e.Message,
+		"ecs.version": "1.6.0",
+	}
+	for k, v := range e.Data {
+		if n, ok := ecsFields[k]; ok {
+			k = n
+		}
+		m[k] = value(v)
+	}
+	if e.HasCaller() {
+		m["log.origin.file.name"] = e.Caller.File
+		m["log.origin.file.line"] = e.Caller.Line
+		m["log.origin.function"] = e.Caller.Function
+	}
+	return marshal(m)
+}
+
+// value returns the message of errors, which aren't marshalled otherwise
+func value(v interface{}) interface{} {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
+
+func marshal(m map[string]interface{}) ([]byte, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
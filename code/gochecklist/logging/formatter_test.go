@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// entry returns an entry with every kind of field, logged with the caller
+func entry() *logrus.Entry {
+	l := logrus.New()
+	l.SetReportCaller(true)
+	e := logrus.NewEntry(l).WithFields(logrus.Fields{
+		"service":       "myprogram",
+		"version":       "1.0.0",
+		"build":         "abc1234",
+		"id":            42,
+		"path":          "/webhook",
+		logrus.ErrorKey: errors.New("connection refused"),
+	})
+	e.Time = time.Date(2024, 3, 1, 12, 30, 0, 500000000, time.FixedZone("CET", 3600))
+	e.Level = logrus.WarnLevel
+	e.Message = "Couldn't reach the database"
+	e.Caller = &runtime.Frame{File: "/src/myprogram/cmd/serve.go", Line: 42, Function: "github.com/me/myprogram/cmd.serve"}
+	return e
+}
+
+func TestFormatters(t *testing.T) {
+	tests := []struct {
+		golden string
+		f      logrus.Formatter
+	}{
+		{"gelf.json", &GELFFormatter{Host: "myhost"}},
+		{"ecs.json", &ECSFormatter{}},
+		{"logfmt.txt", mustFormatter(t, "logfmt")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			b, err := tt.f.Format(entry())
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, b, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, want) {
+				t.Errorf("output differs from %s, run go test -update to update it:\n%s", golden, b)
+			}
+		})
+	}
+}
+
+func mustFormatter(t *testing.T, format string) logrus.Formatter {
+	f, err := Formatter(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		level  logrus.Level
+		syslog int
+		ecs    string
+	}{
+		{logrus.PanicLevel, 0, "panic"},
+		{logrus.FatalLevel, 2, "fatal"},
+		{logrus.ErrorLevel, 3, "error"},
+		{logrus.WarnLevel, 4, "warning"},
+		{logrus.InfoLevel, 6, "info"},
+		{logrus.DebugLevel, 7, "debug"},
+		{logrus.TraceLevel, 7, "trace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			e := logrus.NewEntry(logrus.New())
+			e.Level = tt.level
+
+			m := decode(t, &GELFFormatter{Host: "myhost"}, e)
+			if m["level"] != float64(tt.syslog) {
+				t.Errorf("got GELF level %v, expected %d", m["level"], tt.syslog)
+			}
+			m = decode(t, &ECSFormatter{}, e)
+			if m["log.level"] != tt.ecs {
+				t.Errorf("got ECS level %v, expected %s", m["log.level"], tt.ecs)
+			}
+		})
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	for _, f := range []string{"text", "logfmt", "json", "gelf", "ecs"} {
+		if _, err := Formatter(f); err != nil {
+			t.Errorf("%s: %v", f, err)
+		}
+	}
+	if _, err := Formatter("xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
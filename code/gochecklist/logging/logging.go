@@ -0,0 +1,152 @@
+// Package logging configures logrus from the log configuration: format,
+// output, rotation, level routing and static fields.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/Depado/articles/code/gochecklist/config"
+)
+
+// Static holds the fields attached to every entry
+type Static struct {
+	Service string
+	Version string
+	Build   string
+}
+
+// Setup configures the standard logger. It can be called again when the
+// configuration changes, the hooks, formatter and output being replaced. The
+// returned Closer releases the output, a log file for example, and should be
+// called once the logger was configured with another output.
+func Setup(c config.Log, s Static) (io.Closer, error) {
+	return configure(logrus.StandardLogger(), c, s, os.Stdout, os.Stderr)
+}
+
+// configure configures the logger, stdout and stderr being the standard
+// outputs
+func configure(logger *logrus.Logger, c config.Log, s Static, stdout, stderr io.Writer) (io.Closer, error) {
+	f, err := Formatter(c.Format)
+	if err != nil {
+		return nil, err
+	}
+	l, err := logrus.ParseLevel(c.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	out := output(c, stdout, stderr)
+	hooks := make(logrus.LevelHooks)
+	if c.Static {
+		hooks.Add(&staticHook{s})
+	}
+
+	logger.ReplaceHooks(hooks)
+	logger.SetLevel(l)
+	logger.SetReportCaller(c.Line)
+	if c.Stderr {
+		so := &splitOutput{out: out, err: stderr}
+		logger.SetFormatter(&splitFormatter{Formatter: f, out: so})
+		logger.SetOutput(so)
+	} else {
+		logger.SetFormatter(f)
+		logger.SetOutput(out)
+	}
+	return out, nil
+}
+
+// output returns the output of the configuration, a rotated log file unless
+// it's one of the standard outputs
+func output(c config.Log, stdout, stderr io.Writer) io.WriteCloser {
+	switch c.Output {
+	case "stdout":
+		return nopWriter{stdout}
+	case "stderr":
+		return nopWriter{stderr}
+	}
+	return &lumberjack.Logger{
+		Filename:   c.Output,
+		MaxSize:    c.Rotate.MaxSize,
+		MaxAge:     c.Rotate.MaxAge,
+		MaxBackups: c.Rotate.MaxBackups,
+		Compress:   c.Rotate.Compress,
+	}
+}
+
+// Formatter returns the logrus formatter of the given format
+func Formatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "text":
+		return &logrus.TextFormatter{}, nil
+	case "logfmt":
+		// The text format is logfmt once colors are disabled, the keys being
+		// sorted. The full timestamp and quoted empty values make it stable
+		// whether the output is a terminal or not.
+		return &logrus.TextFormatter{DisableColors: true, FullTimestamp: true, QuoteEmptyFields: true}, nil
+	case "json":
+		return &logrus.JSONFormatter{}, nil
+	case "gelf":
+		return newGELFFormatter(), nil
+	case "ecs":
+		return &ECSFormatter{}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// nopWriter doesn't close the standard outputs
+type nopWriter struct {
+	io.Writer
+}
+
+func (nopWriter) Close() error { return nil }
+
+// staticHook adds the static fields to every entry, unless already set
+type staticHook struct {
+	s Static
+}
+
+func (h *staticHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *staticHook) Fire(e *logrus.Entry) error {
+	for k, v := range map[string]string{"service": h.s.Service, "version": h.s.Version, "build": h.s.Build} {
+		if _, ok := e.Data[k]; !ok && v != "" {
+			e.Data[k] = v
+		}
+	}
+	return nil
+}
+
+// splitOutput writes the error, fatal and panic entries to err and the other
+// ones to out, the level of the entry being recorded by splitFormatter. This
+// relies on logrus formatting and writing an entry while holding the logger
+// mutex, so that the recorded level is the one of the entry being written.
+type splitOutput struct {
+	out   io.Writer
+	err   io.Writer
+	level logrus.Level
+}
+
+func (s *splitOutput) Write(b []byte) (int, error) {
+	if s.level <= logrus.ErrorLevel {
+		return s.err.Write(b)
+	}
+	return s.out.Write(b)
+}
+
+// splitFormatter records the level of the entries it formats for splitOutput
+type splitFormatter struct {
+	logrus.Formatter
+	out *splitOutput
+}
+
+func (f *splitFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	f.out.level = e.Level
+	return f.Formatter.Format(e)
+}
@@ -0,0 +1,171 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/Depado/articles/code/gochecklist/config"
+)
+
+// decode formats the entry and decodes the resulting JSON object
+func decode(t *testing.T, f logrus.Formatter, e *logrus.Entry) map[string]interface{} {
+	t.Helper()
+	b, err := f.Format(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("%v: %s", err, b)
+	}
+	return m
+}
+
+// lines returns the non-empty lines written in b
+func lines(b *bytes.Buffer) []string {
+	return strings.FieldsFunc(b.String(), func(r rune) bool { return r == '\n' })
+}
+
+func TestStatic(t *testing.T) {
+	var stdout bytes.Buffer
+	l := logrus.New()
+	c := config.Log{Level: "info", Format: "ecs", Output: "stdout", Static: true}
+	if _, err := configure(l, c, Static{Service: "myprogram", Version: "1.0.0", Build: "abc1234"}, &stdout, nil); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("Started")
+	l.WithField("version", "2.0.0").Info("Overridden")
+
+	ls := lines(&stdout)
+	if len(ls) != 2 {
+		t.Fatalf("got %d lines, expected 2: %q", len(ls), ls)
+	}
+	for i, want := range []map[string]interface{}{
+		{"service.name": "myprogram", "service.version": "1.0.0", "labels.build": "abc1234"},
+		{"service.name": "myprogram", "service.version": "2.0.0", "labels.build": "abc1234"},
+	} {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(ls[i]), &m); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range want {
+			if m[k] != v {
+				t.Errorf("line %d: got %s %v, expected %v", i, k, m[k], v)
+			}
+		}
+	}
+
+	// The static fields are only added when enabled
+	stdout.Reset()
+	c.Static = false
+	if _, err := configure(l, c, Static{Service: "myprogram"}, &stdout, nil); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("Started")
+	if strings.Contains(stdout.String(), "service.name") {
+		t.Errorf("static fields added: %s", stdout.String())
+	}
+}
+
+func TestStderr(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	l := logrus.New()
+	l.ExitFunc = func(int) {}
+	c := config.Log{Level: "debug", Format: "json", Output: "stdout", Stderr: true}
+	if _, err := configure(l, c, Static{}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	l.Fatal("fatal")
+
+	msgs := func(b *bytes.Buffer) []string {
+		var ms []string
+		for _, line := range lines(b) {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				t.Fatalf("%v: %s", err, line)
+			}
+			ms = append(ms, m["msg"].(string))
+		}
+		return ms
+	}
+	if got := msgs(&stdout); !reflect.DeepEqual(got, []string{"debug", "info", "warn"}) {
+		t.Errorf("got %q on stdout", got)
+	}
+	if got := msgs(&stderr); !reflect.DeepEqual(got, []string{"error", "fatal"}) {
+		t.Errorf("got %q on stderr", got)
+	}
+
+	// Every entry is formatted once and written whole to the right output
+	stdout.Reset()
+	stderr.Reset()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Info("info")
+				l.Error("error")
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := len(msgs(&stdout)), 500; got != want {
+		t.Errorf("got %d entries on stdout, expected %d", got, want)
+	}
+	for _, m := range msgs(&stderr) {
+		if m != "error" {
+			t.Fatalf("got %q on stderr", m)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if w := output(config.Log{Output: "stdout"}, &stdout, &stderr); w.(nopWriter).Writer != &stdout {
+		t.Error("stdout not used")
+	}
+	if w := output(config.Log{Output: "stderr"}, &stdout, &stderr); w.(nopWriter).Writer != &stderr {
+		t.Error("stderr not used")
+	}
+
+	p := filepath.Join(t.TempDir(), "myprogram.log")
+	c := config.Log{Level: "info", Format: "logfmt", Output: p, Rotate: config.Rotate{MaxSize: 10, MaxAge: 7, MaxBackups: 3, Compress: true}}
+	want := &lumberjack.Logger{Filename: p, MaxSize: 10, MaxAge: 7, MaxBackups: 3, Compress: true}
+	if w := output(c, &stdout, &stderr); !reflect.DeepEqual(w, want) {
+		t.Errorf("got %+v, expected %+v", w, want)
+	}
+
+	l := logrus.New()
+	closer, err := configure(l, c, Static{}, &stdout, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.WithField("path", "/webhook").Info("Started")
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `level=info msg=Started path=/webhook`) {
+		t.Errorf("unexpected log file %q", b)
+	}
+	if stdout.Len() != 0 || stderr.Len() != 0 {
+		t.Error("standard outputs written")
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
-import "github.com/Depado/articles/code/gochecklist/cmd"
+import (
+	"github.com/Depado/articles/code/gochecklist/buildinfo"
+	"github.com/Depado/articles/code/gochecklist/cmd"
+)
 
-// Build number and versions injected at compile time
+// Build number, versions and build date injected at compile time
 var (
 	Version = "unknown"
 	Build   = "unknown"
+	Date    = ""
 )
 
 func main() {
-	cmd.Execute(Version, Build)
+	cmd.Execute(buildinfo.New(Version, Build, Date))
 }
@@ -0,0 +1,72 @@
+// Package plugins discovers and runs the external commands of the program,
+// executables named <program>-<name> found in a plugins directory or in the
+// PATH.
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Plugin is an external command
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Discover returns the plugins of the program found in dirs then in the PATH,
+// sorted by name. The first executable found for a name shadows the others.
+func Discover(program string, dirs ...string) []Plugin {
+	prefix := program + "-"
+	seen := make(map[string]bool)
+	var ps []Plugin
+	for _, d := range append(dirs, filepath.SplitList(os.Getenv("PATH"))...) {
+		if d == "" {
+			continue
+		}
+		fis, err := ioutil.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, fi := range fis {
+			name := strings.TrimSuffix(fi.Name(), ".exe")
+			if !strings.HasPrefix(name, prefix) || name == prefix {
+				continue
+			}
+			name = strings.TrimPrefix(name, prefix)
+			p := filepath.Join(d, fi.Name())
+			if seen[name] || !executable(p) {
+				continue
+			}
+			seen[name] = true
+			ps = append(ps, Plugin{Name: name, Path: p})
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	return ps
+}
+
+// executable returns whether p is an executable file, following symlinks
+func executable(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.Mode().IsRegular() && fi.Mode()&0111 != 0
+}
+
+// Run executes the plugin with args, the standard streams and environment of
+// the program along with env, and returns its exit code
+func (p Plugin) Run(args []string, env ...string) (int, error) {
+	c := exec.Command(p.Path, args...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	c.Env = append(os.Environ(), env...)
+	if err := c.Run(); err != nil {
+		if e, ok := err.(*exec.ExitError); ok {
+			return e.ExitCode(), nil
+		}
+		return 1, err
+	}
+	return 0, nil
+}
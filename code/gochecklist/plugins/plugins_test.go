@@ -0,0 +1,108 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// write writes a file in dir with the given mode and returns its path
+func write(t *testing.T, dir, name, content string, mode os.FileMode) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+const script = "#!/bin/sh\nexit 0\n"
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the executable bit isn't used on windows")
+	}
+	conf, path1, path2 := t.TempDir(), t.TempDir(), t.TempDir()
+
+	hello := write(t, conf, "myprogram-hello", script, 0o755)
+	write(t, conf, "myprogram-notes.txt", "not a plugin", 0o644)
+	if err := os.Mkdir(filepath.Join(conf, "myprogram-dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write(t, path1, "myprogram-hello", script, 0o755)
+	deploy := write(t, path1, "myprogram-deploy", script, 0o755)
+	write(t, path1, "myprogram-", script, 0o755)
+	write(t, path1, "otherprogram-hello", script, 0o755)
+	write(t, path1, "myprogramhello", script, 0o755)
+	write(t, path2, "myprogram-deploy", script, 0o755)
+	lint := write(t, path2, "myprogram-lint.exe", script, 0o755)
+	if err := os.Symlink(hello, filepath.Join(path2, "myprogram-link")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", strings.Join([]string{path1, "", filepath.Join(path1, "missing"), path2}, string(os.PathListSeparator)))
+
+	want := []Plugin{
+		{Name: "deploy", Path: deploy},
+		{Name: "hello", Path: hello},
+		{Name: "link", Path: filepath.Join(path2, "myprogram-link")},
+		{Name: "lint", Path: lint},
+	}
+	if got := Discover("myprogram", conf); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+
+	// Without the directory the PATH one isn't shadowed anymore
+	want[1].Path = filepath.Join(path1, "myprogram-hello")
+	if got := Discover("myprogram"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+
+	if got := Discover("otherprogram"); len(got) != 1 || got[0].Name != "hello" {
+		t.Errorf("got %+v, expected the hello plugin of otherprogram", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	p := Plugin{Name: "hello", Path: write(t, dir, "myprogram-hello", `#!/bin/sh
+echo "$@" "$MYPROGRAM_CONF" > "$OUT"
+exit $1
+`, 0o755)}
+	t.Setenv("OUT", out)
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"0", "y"}, 0},
+		{[]string{"3", "--log.level=debug"}, 3},
+		{[]string{"42"}, 42},
+	}
+	for _, tt := range tests {
+		code, err := p.Run(tt.args, "MYPROGRAM_CONF=/etc/myprogram/conf.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("%v: got exit code %d, expected %d", tt.args, code, tt.code)
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Join(tt.args, " ") + " /etc/myprogram/conf.yaml\n"; string(b) != want {
+			t.Errorf("plugin got %q, expected %q", b, want)
+		}
+	}
+
+	if _, err := (Plugin{Name: "missing", Path: filepath.Join(dir, "missing")}).Run(nil); err == nil {
+		t.Error("expected an error running a missing plugin")
+	}
+}
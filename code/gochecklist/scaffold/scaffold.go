@@ -0,0 +1,166 @@
+// Package scaffold renders a new project following the checklist from the
+// embedded templates: main.go with the version variables, cobra commands with
+// viper configuration and logger setup, Makefile, Dockerfile, license and lint
+// configuration.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Licenses are the licenses a project can be created with, none omitting the
+// LICENSE file
+var Licenses = []string{"mit", "apache-2.0", "bsd-3-clause", "none"}
+
+// Options describes the project and selects its optional components
+type Options struct {
+	// Module is the module path of the project
+	Module string
+	// Name is the name of the binary, the last element of the module path if
+	// empty
+	Name      string
+	Author    string
+	Year      int
+	GoVersion string
+	License   string
+	Makefile  bool
+	Docker    bool
+	Lint      bool
+}
+
+// EnvPrefix is the prefix of the environment variables of the project
+func (o Options) EnvPrefix() string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(o.Name))
+}
+
+func (o Options) validate() error {
+	m := o.Module
+	if m == "" || strings.ContainsAny(m, " \t\n\\") || strings.HasPrefix(m, "/") ||
+		strings.HasSuffix(m, "/") || strings.Contains(m, "..") || strings.Contains(m, "//") {
+		return fmt.Errorf("invalid module path %q", m)
+	}
+	for _, l := range Licenses {
+		if o.License == l {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown license %q, expected one of %s", o.License, strings.Join(Licenses, ", "))
+}
+
+// NameOf returns the name of the binary of a module, its last element unless
+// it's a major version suffix
+func NameOf(module string) string {
+	els := strings.Split(module, "/")
+	name := els[len(els)-1]
+	if len(els) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = els[len(els)-2]
+	}
+	return name
+}
+
+// File is a rendered file, its path being relative to the project directory
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// component is a file of the project, rendered from tmpl when enabled
+type component struct {
+	tmpl    string
+	path    string
+	enabled func(Options) bool
+}
+
+func always(Options) bool { return true }
+
+var components = []component{
+	{"go.mod.tmpl", "go.mod", always},
+	{"main.go.tmpl", "main.go", always},
+	{"cmd/root.go.tmpl", "cmd/root.go", always},
+	{"cmd/version.go.tmpl", "cmd/version.go", always},
+	{"gitignore.tmpl", ".gitignore", always},
+	{"Makefile.tmpl", "Makefile", func(o Options) bool { return o.Makefile }},
+	{"Dockerfile.tmpl", "Dockerfile", func(o Options) bool { return o.Docker }},
+	{"golangci.yml.tmpl", ".golangci.yml", func(o Options) bool { return o.Lint }},
+}
+
+// Render renders the files of the project, the Go files being formatted
+func Render(o Options) ([]File, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if o.Name == "" {
+		o.Name = NameOf(o.Module)
+	}
+	cs := append([]component{}, components...)
+	if o.License != "none" {
+		cs = append(cs, component{"licenses/" + o.License + ".tmpl", "LICENSE", always})
+	}
+
+	var fs []File
+	for _, c := range cs {
+		if !c.enabled(o) {
+			continue
+		}
+		b, err := render(c.tmpl, o)
+		if err != nil {
+			return nil, err
+		}
+		if path.Ext(c.path) == ".go" {
+			if b, err = format.Source(b); err != nil {
+				return nil, fmt.Errorf("couldn't format %s: %v", c.path, err)
+			}
+		}
+		fs = append(fs, File{Path: c.path, Content: b})
+	}
+	return fs, nil
+}
+
+func render(name string, o Options) ([]byte, error) {
+	raw, err := templates.ReadFile("templates/" + name)
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.New(name).Parse(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, o); err != nil {
+		return nil, fmt.Errorf("couldn't render %s: %v", name, err)
+	}
+	return b.Bytes(), nil
+}
+
+// Write writes the files in dir. Nothing is written if any of them already
+// exists.
+func Write(dir string, fs []File) error {
+	for _, f := range fs {
+		p := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if _, err := os.Stat(p); err == nil {
+			return fmt.Errorf("%s already exists", p)
+		}
+	}
+	for _, f := range fs {
+		p := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, f.Content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
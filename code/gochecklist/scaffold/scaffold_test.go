@@ -0,0 +1,197 @@
+package scaffold
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func options() Options {
+	return Options{
+		Module:    "github.com/me/myprogram",
+		Author:    "Me",
+		Year:      2024,
+		GoVersion: "1.22",
+		License:   "mit",
+		Makefile:  true,
+		Docker:    true,
+		Lint:      true,
+	}
+}
+
+// paths returns the paths of the rendered files
+func paths(fs []File) map[string]bool {
+	m := make(map[string]bool, len(fs))
+	for _, f := range fs {
+		m[f.Path] = true
+	}
+	return m
+}
+
+func TestRenderLicenses(t *testing.T) {
+	for _, l := range Licenses {
+		t.Run(l, func(t *testing.T) {
+			o := options()
+			o.License = l
+			fs, err := Render(o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ps := paths(fs)
+			if ps["LICENSE"] == (l == "none") {
+				t.Errorf("LICENSE rendered: %v", ps["LICENSE"])
+			}
+			// The Apache license carries no copyright notice
+			for _, f := range fs {
+				if f.Path == "LICENSE" && l != "apache-2.0" && !bytes.Contains(f.Content, []byte("2024 Me")) && !bytes.Contains(f.Content, []byte("2024, Me")) {
+					t.Errorf("LICENSE doesn't contain the copyright:\n%s", f.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderComponents(t *testing.T) {
+	optional := map[string]func(*Options, bool){
+		"Makefile":      func(o *Options, b bool) { o.Makefile = b },
+		"Dockerfile":    func(o *Options, b bool) { o.Docker = b },
+		".golangci.yml": func(o *Options, b bool) { o.Lint = b },
+	}
+	for p, set := range optional {
+		for _, enabled := range []bool{true, false} {
+			o := options()
+			set(&o, enabled)
+			fs, err := Render(o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ps := paths(fs)
+			if ps[p] != enabled {
+				t.Errorf("%s rendered %v, expected %v", p, ps[p], enabled)
+			}
+			for _, required := range []string{"go.mod", "main.go", "cmd/root.go", "cmd/version.go", ".gitignore"} {
+				if !ps[required] {
+					t.Errorf("%s not rendered", required)
+				}
+			}
+		}
+	}
+}
+
+func TestRenderFormatted(t *testing.T) {
+	fs, err := Render(options())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fs {
+		switch {
+		case path.Ext(f.Path) == ".go":
+			b, err := format.Source(f.Content)
+			if err != nil {
+				t.Fatalf("%s: %v", f.Path, err)
+			}
+			if !bytes.Equal(b, f.Content) {
+				t.Errorf("%s isn't formatted", f.Path)
+			}
+		case f.Path == "go.mod":
+			if !bytes.HasPrefix(f.Content, []byte("module github.com/me/myprogram\n")) {
+				t.Errorf("unexpected go.mod:\n%s", f.Content)
+			}
+		}
+		if f.Path == "cmd/root.go" && !bytes.Contains(f.Content, []byte(`viper.SetEnvPrefix("MYPROGRAM")`)) {
+			t.Errorf("cmd/root.go doesn't set the environment prefix:\n%s", f.Content)
+		}
+	}
+}
+
+func TestNameOf(t *testing.T) {
+	tests := []struct {
+		module string
+		name   string
+	}{
+		{"github.com/me/myprogram", "myprogram"},
+		{"github.com/me/myprogram/v2", "myprogram"},
+		{"github.com/me/myprogram/v10", "myprogram"},
+		{"github.com/me/v2ray", "v2ray"},
+		{"github.com/me/myprogram/v", "v"},
+		{"myprogram", "myprogram"},
+		{"v2", "v2"},
+	}
+	for _, tt := range tests {
+		if got := NameOf(tt.module); got != tt.name {
+			t.Errorf("NameOf(%q) = %q, expected %q", tt.module, got, tt.name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Options)
+		err  string
+	}{
+		{"valid", func(*Options) {}, ""},
+		{"empty module", func(o *Options) { o.Module = "" }, "invalid module path"},
+		{"space", func(o *Options) { o.Module = "github.com/me/my program" }, "invalid module path"},
+		{"backslash", func(o *Options) { o.Module = `github.com\me` }, "invalid module path"},
+		{"absolute", func(o *Options) { o.Module = "/me/myprogram" }, "invalid module path"},
+		{"trailing slash", func(o *Options) { o.Module = "github.com/me/" }, "invalid module path"},
+		{"dot dot", func(o *Options) { o.Module = "github.com/../me" }, "invalid module path"},
+		{"double slash", func(o *Options) { o.Module = "github.com//me" }, "invalid module path"},
+		{"unknown license", func(o *Options) { o.License = "gpl" }, `unknown license "gpl"`},
+		{"empty license", func(o *Options) { o.License = "" }, `unknown license ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options()
+			tt.edit(&o)
+			err := o.validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, expected %q", err, tt.err)
+			}
+			if _, err := Render(o); err == nil {
+				t.Error("expected Render to fail")
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	fs := []File{
+		{Path: "main.go", Content: []byte("package main\n")},
+		{Path: "cmd/root.go", Content: []byte("package cmd\n")},
+	}
+	if err := Write(dir, fs); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "cmd", "root.go"))
+	if err != nil || string(b) != "package cmd\n" {
+		t.Fatalf("got %q (%v), expected the written file", b, err)
+	}
+
+	// Nothing is written when a single file exists
+	fs = []File{
+		{Path: "Makefile", Content: []byte("all:\n")},
+		{Path: "main.go", Content: []byte("package other\n")},
+	}
+	if err := Write(dir, fs); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("got %v, expected an already exists error", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Makefile")); !os.IsNotExist(err) {
+		t.Errorf("Makefile written despite main.go existing")
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(b) != "package main\n" {
+		t.Errorf("main.go overwritten: %q", b)
+	}
+}